@@ -0,0 +1,65 @@
+package logger
+
+import "sync"
+
+var asyncLevelsMu sync.RWMutex
+var asyncLevels = map[string]bool{}
+
+// SetAsyncLevels sets the levels that Log, Debug, Info, Warning and Error write asynchronously.
+// Entries of the given levels are written by the asynchronous writer in call order (see StartAsync), so the call
+// returns before the entry reaches the log file, while all other levels block until the entry is written.
+// This trades durability for latency: asynchronous entries are lost if the application exits or crashes
+// before they were written, so it's recommended to keep ERROR and more severe levels synchronous.
+// FATAL entries are always written synchronously. Passing nil or an empty slice makes all levels synchronous.
+func SetAsyncLevels(levels []string) {
+	asyncLevelsMu.Lock()
+	defer asyncLevelsMu.Unlock()
+
+	asyncLevels = map[string]bool{}
+	for _, level := range levels {
+		level, _ = resolveLevel(level)
+		asyncLevels[level] = true
+	}
+}
+
+// isAsyncLevel reports whether entries of the given level are written asynchronously.
+func isAsyncLevel(level string) bool {
+	if level == LevelFatal {
+		return false
+	}
+
+	asyncLevelsMu.RLock()
+	defer asyncLevelsMu.RUnlock()
+
+	return asyncLevels[level]
+}
+
+// dispatch writes the entry synchronously or asynchronously depending on the levels set by SetAsyncLevels.
+func dispatch(level string, content string) {
+	std.dispatch(level, content)
+}
+
+// dispatch writes the entry with the logger synchronously or asynchronously depending on the levels set by SetAsyncLevels.
+func (lg *Logger) dispatch(level string, content string) {
+	lg.dispatchFields(level, content, nil)
+}
+
+// dispatchFields writes the entry with fields like dispatch.
+func (lg *Logger) dispatchFields(level string, content string, fields map[string]interface{}) {
+	if disabled.Load() {
+		return
+	}
+
+	if isAsyncLevel(level) {
+		// copy the fields, as the caller may modify the map after returning
+		fieldsCopy := make(map[string]interface{}, len(fields))
+		for key, value := range fields {
+			fieldsCopy[key] = value
+		}
+
+		goAsync(func() { lg.lf(level, content, fieldsCopy) })
+		return
+	}
+
+	lg.lf(level, content, fields)
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockAsyncWriter queues an entry that blocks the asynchronous writer until the returned function is called.
+func blockAsyncWriter(t *testing.T) func() {
+	t.Helper()
+
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	goAsync(func() {
+		close(blocked)
+		<-release
+	})
+	<-blocked
+
+	return func() {
+		close(release)
+	}
+}
+
+// waitAsync waits until all asynchronous entries were written.
+func waitAsync(t *testing.T) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for asyncPending.Load() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("asynchronous entries still pending: %d", asyncPending.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAsyncLevels(t *testing.T) {
+	buf := captureOutput(t)
+
+	SetAsyncLevels([]string{LevelInfo})
+	t.Cleanup(func() {
+		SetAsyncLevels(nil)
+	})
+
+	release := blockAsyncWriter(t)
+	Info("asynchronous entry")
+	if strings.Contains(buf.String(), "asynchronous entry") {
+		t.Error("expected INFO to return before the entry is written")
+	}
+
+	Error("synchronous entry")
+	if !strings.Contains(buf.String(), "synchronous entry") {
+		t.Error("expected ERROR to be written before returning")
+	}
+
+	release()
+	waitAsync(t)
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 2 || !strings.Contains(lines[1], "asynchronous entry") {
+		t.Errorf("expected the asynchronous entry after the synchronous one, got %q", lines)
+	}
+}
+
+func TestInfoAsyncOrderInFile(t *testing.T) {
+	dir := setupLogDir(t)
+	// no entry may be dropped, whatever the size of the queue
+	useOverflowPolicy(t, OverflowBlock)
+
+	for i := 0; i < 200; i++ {
+		InfoAsync(fmt.Sprintf("entry %d", i))
+	}
+	waitAsync(t)
+
+	lines := readMainLog(t, dir)
+	if len(lines) != 200 {
+		t.Fatalf("expected 200 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, fmt.Sprintf("INFO entry %d", i)) {
+			t.Fatalf("expected entry %d at line %d, got %q", i, i, line)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// policies for a full queue of the asynchronous writer, see SetOverflowPolicy
+const (
+	// OverflowBlock makes the asynchronous logging functions wait until there's room in the queue.
+	OverflowBlock = "block"
+
+	// OverflowDropNew drops the new entry and counts it in Metrics.DroppedAsync.
+	OverflowDropNew = "drop_new"
+
+	// OverflowDropOld drops the oldest queued entry to make room for the new one and counts it in
+	// Metrics.DroppedAsync.
+	OverflowDropOld = "drop_old"
+)
+
+// asyncQueueMu guards asyncQueue and overflowPolicy.
+var asyncQueueMu sync.RWMutex
+var asyncQueue chan func()
+var overflowPolicy = OverflowDropNew
+var asyncWorkerID atomic.Uint64
+var asyncDropped atomic.Uint64
+
+// asyncDroppedUnreported is the number of dropped entries the writer hasn't emitted EventBufferOverflowed for.
+var asyncDroppedUnreported atomic.Uint64
+
+// StartAsync creates the queue of the asynchronous logging with room for bufferSize entries.
+// All asynchronous entries, i.e. of LogAsync, InfoAsync etc. and of the levels set by SetAsyncLevels, go through
+// this queue and are written by a single goroutine, so they're written in the order of the calls and never
+// interleaved. When the queue is full, the policy set by SetOverflowPolicy decides what happens. Shutdown waits until the queue
+// is drained. A bufferSize of 0 or less uses a queue of 1024 entries, which is also created by the first
+// asynchronous entry if StartAsync wasn't called before. Once the queue exists, calling StartAsync has no effect.
+// Note that the order is only guaranteed among the asynchronous entries: a synchronous entry logged after an
+// asynchronous one may still be written first.
+func StartAsync(bufferSize int) {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+
+	asyncQueueMu.Lock()
+	defer asyncQueueMu.Unlock()
+
+	if asyncQueue != nil {
+		return
+	}
+
+	asyncQueue = make(chan func(), bufferSize)
+	go runAsyncWriter(asyncQueue)
+}
+
+// SetOverflowPolicy sets what happens when the queue of the asynchronous writer is full: OverflowBlock makes the
+// caller wait, OverflowDropNew drops the new entry and OverflowDropOld drops the oldest queued entry. Dropped
+// entries are counted in Metrics.DroppedAsync. Default: OverflowDropNew, so logging never blocks the application.
+// An unknown policy returns an error and leaves the current policy unchanged.
+func SetOverflowPolicy(policy string) error {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if policy != OverflowBlock && policy != OverflowDropNew && policy != OverflowDropOld {
+		return errors.New("unknown overflow policy " + policy)
+	}
+
+	asyncQueueMu.Lock()
+	defer asyncQueueMu.Unlock()
+
+	overflowPolicy = policy
+	return nil
+}
+
+// runAsyncWriter runs the queued functions in order.
+func runAsyncWriter(queue chan func()) {
+	asyncWorkerID.Store(goroutineID())
+
+	for fn := range queue {
+		fn()
+		asyncPending.Add(-1)
+
+		// report drops from here, as an entry logged by the dropping caller could be dropped again
+		dropped := asyncDroppedUnreported.Swap(0)
+		if dropped > 0 {
+			logLifecycleEvent(EventBufferOverflowed, map[string]interface{}{"buffer": "async", "dropped": dropped})
+		}
+	}
+}
+
+// countAsyncDrop counts an entry dropped because the queue was full.
+func countAsyncDrop() {
+	asyncDropped.Add(1)
+	asyncDroppedUnreported.Add(1)
+	asyncPending.Add(-1)
+}
+
+// enqueueAsync queues fn for the asynchronous writer, which is started if needed, drops it or runs it right away.
+// asyncPending must already count fn.
+func enqueueAsync(fn func()) {
+	asyncQueueMu.RLock()
+	queue := asyncQueue
+	policy := overflowPolicy
+	asyncQueueMu.RUnlock()
+
+	if queue == nil {
+		StartAsync(0)
+
+		asyncQueueMu.RLock()
+		queue = asyncQueue
+		asyncQueueMu.RUnlock()
+	}
+
+	// queue without waiting, which succeeds unless the queue is full
+	select {
+	case queue <- fn:
+		return
+	default:
+	}
+
+	switch policy {
+	case OverflowBlock:
+		// functions queued by the writer itself, e.g. a lifecycle event of a level set by SetAsyncLevels, are
+		// run right away, as the writer would wait for itself; parsing the goroutine ID is slow, but only
+		// needed once the queue is full
+		if goroutineID() == asyncWorkerID.Load() {
+			fn()
+			asyncPending.Add(-1)
+			return
+		}
+
+		queue <- fn
+	case OverflowDropOld:
+		for {
+			select {
+			case queue <- fn:
+				return
+			default:
+			}
+
+			// the queue is full, drop the oldest entry
+			select {
+			case <-queue:
+				countAsyncDrop()
+			default:
+			}
+		}
+	default:
+		countAsyncDrop()
+	}
+}
@@ -0,0 +1,227 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useOverflowPolicy sets the overflow policy of the asynchronous writer for the duration of the test.
+func useOverflowPolicy(t *testing.T, policy string) {
+	t.Helper()
+
+	err := SetOverflowPolicy(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetOverflowPolicy(OverflowDropNew)
+	})
+}
+
+// fillAsyncQueueWithEntries blocks the asynchronous writer and queues the INFO entries "queued 0", "queued 1",
+// ... until the queue is full. It returns the function that releases the writer and the number of entries.
+func fillAsyncQueueWithEntries(t *testing.T) (func(), int) {
+	t.Helper()
+
+	release := blockAsyncWriter(t)
+
+	asyncQueueMu.RLock()
+	queue := asyncQueue
+	asyncQueueMu.RUnlock()
+
+	n := cap(queue) - len(queue)
+	for i := 0; i < n; i++ {
+		InfoAsync(fmt.Sprintf("queued %d", i))
+	}
+
+	return release, n
+}
+
+func TestOverflowDropNew(t *testing.T) {
+	buf := captureOutput(t)
+	useOverflowPolicy(t, OverflowDropNew)
+
+	release, n := fillAsyncQueueWithEntries(t)
+	InfoAsync("newest")
+	release()
+	waitAsync(t)
+
+	output := buf.String()
+	if strings.Contains(output, "newest") {
+		t.Error("expected the new entry to be dropped")
+	}
+	if !strings.Contains(output, "INFO queued 0\n") || !strings.Contains(output, fmt.Sprintf("INFO queued %d\n", n-1)) {
+		t.Error("expected all queued entries to be written")
+	}
+}
+
+func TestOverflowDropOld(t *testing.T) {
+	buf := captureOutput(t)
+	useOverflowPolicy(t, OverflowDropOld)
+
+	release, n := fillAsyncQueueWithEntries(t)
+	InfoAsync("newest")
+	release()
+	waitAsync(t)
+
+	output := buf.String()
+	if strings.Contains(output, "INFO queued 0\n") {
+		t.Error("expected the oldest entry to be dropped")
+	}
+	if !strings.Contains(output, "INFO queued 1\n") || !strings.Contains(output, fmt.Sprintf("INFO queued %d\n", n-1)) {
+		t.Error("expected the other queued entries to be written")
+	}
+	if !strings.HasSuffix(output, "INFO newest\n") {
+		t.Error("expected the new entry to be written last")
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	buf := captureOutput(t)
+	useOverflowPolicy(t, OverflowBlock)
+
+	release, _ := fillAsyncQueueWithEntries(t)
+	returned := make(chan struct{})
+	go func() {
+		InfoAsync("newest")
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+		t.Fatal("expected the call to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	<-returned
+	waitAsync(t)
+
+	if !strings.HasSuffix(buf.String(), "INFO newest\n") {
+		t.Error("expected the blocked entry to be written last")
+	}
+}
+
+func TestAsyncOrder(t *testing.T) {
+	buf := captureOutput(t)
+	useOverflowPolicy(t, OverflowBlock)
+	StartAsync(16)
+
+	// more entries than fit into the queue, even if an earlier test created it with the default size, so the
+	// caller waits for the writer in between
+	n := 2000
+	for i := 0; i < n; i++ {
+		InfoAsync(fmt.Sprintf("entry %d", i))
+	}
+	waitAsync(t)
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != n {
+		t.Fatalf("expected %d entries, got %d", n, len(lines))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, fmt.Sprintf("INFO entry %d", i)) {
+			t.Fatalf("expected entry %d at line %d, got %q", i, i, line)
+		}
+	}
+}
+
+func TestShutdownDrainsAsyncQueue(t *testing.T) {
+	buf := captureOutput(t)
+
+	release := blockAsyncWriter(t)
+	for i := 0; i < 10; i++ {
+		InfoAsync(fmt.Sprintf("queued %d", i))
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		release()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := Shutdown(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 11 {
+		t.Fatalf("expected the 10 queued entries and the summary, got %q", lines)
+	}
+	for i := 0; i < 10; i++ {
+		if !strings.HasSuffix(lines[i], fmt.Sprintf("INFO queued %d", i)) {
+			t.Errorf("expected queued entry %d at line %d, got %q", i, i, lines[i])
+		}
+	}
+	if !strings.Contains(lines[10], "logger shutdown") {
+		t.Errorf("expected the summary after the queued entries, got %q", lines[10])
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	buf := captureOutput(t)
+
+	release := blockAsyncWriter(t)
+	InfoAsync("queued")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if strings.Contains(buf.String(), "INFO queued") {
+		t.Error("expected the blocked entry not to be written before Shutdown returned")
+	}
+	if !strings.Contains(buf.String(), "logger shutdown") {
+		t.Error("expected the summary to be written despite the deadline")
+	}
+
+	release()
+	waitAsync(t)
+}
+
+func TestOverflowBlockFromWriter(t *testing.T) {
+	buf := captureOutput(t)
+	useOverflowPolicy(t, OverflowBlock)
+
+	release := blockAsyncWriter(t)
+	// queued right after the blocking entry, so the writer runs it while the queue is still full
+	goAsync(func() {
+		InfoAsync("from the writer")
+	})
+
+	asyncQueueMu.RLock()
+	queue := asyncQueue
+	asyncQueueMu.RUnlock()
+	for i := cap(queue) - len(queue); i > 0; i-- {
+		InfoAsync(fmt.Sprintf("queued %d", i))
+	}
+	release()
+	waitAsync(t)
+
+	if !strings.Contains(buf.String(), "INFO from the writer\n") {
+		t.Error("expected the entry queued by the writer to be written")
+	}
+}
+
+func BenchmarkInfoAsync(b *testing.B) {
+	setupLogDir(b)
+	SetOverflowPolicy(OverflowBlock)
+	b.Cleanup(func() {
+		SetOverflowPolicy(OverflowDropNew)
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		InfoAsync("benchmark entry")
+	}
+	for asyncPending.Load() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var auditChain = false
+var auditMu sync.Mutex
+var auditPath = ""
+var auditLastHash = ""
+
+// auditRecord is a single line of the audit log.
+// Entries that are valid UTF-8 and end with a newline, i.e. the ones of the text formats, are stored in Entry
+// without the newline. All other entries, e.g. the ones of the protobuf format or with invalid UTF-8, are
+// stored in EntryBase64, as JSON would replace their invalid bytes and the hash wouldn't match anymore.
+type auditRecord struct {
+	Entry       string `json:"entry,omitempty"`
+	EntryBase64 []byte `json:"entry_base64,omitempty"`
+	PrevHash    string `json:"prev_hash"`
+	Hash        string `json:"hash"`
+}
+
+// newAuditRecord returns the record of the formatted entry chained to the given previous hash.
+func newAuditRecord(prevHash string, entry string) auditRecord {
+	record := auditRecord{PrevHash: prevHash}
+	if utf8.ValidString(entry) && strings.HasSuffix(entry, "\n") {
+		record.Entry = strings.TrimSuffix(entry, "\n")
+	} else {
+		record.EntryBase64 = []byte(entry)
+	}
+	record.Hash = auditHash(record.PrevHash, record.hashedEntry())
+
+	return record
+}
+
+// hashedEntry returns the stored entry the hash of the record is computed over.
+func (r auditRecord) hashedEntry() string {
+	if r.EntryBase64 != nil {
+		return string(r.EntryBase64)
+	}
+
+	return r.Entry
+}
+
+// SetAuditChain sets whether every entry is additionally written to the append-only audit log LogDir/audit.log.
+// Each line of the audit log holds the formatted entry, the hash of the previous line and its own hash,
+// which is computed over the previous hash and the entry. Changing, removing or inserting a line breaks
+// the chain, which is detected by VerifyAuditChain.
+func SetAuditChain(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	auditChain = enabled
+}
+
+// auditHash returns the hash of an audit record with the given previous hash and entry.
+func auditHash(prevHash string, entry string) string {
+	sum := sha256.Sum256([]byte(prevHash + "\n" + entry))
+	return hex.EncodeToString(sum[:])
+}
+
+// lastAuditHash returns the hash of the last record in the audit log at the given path,
+// or an empty string if the file doesn't exist or is empty.
+func lastAuditHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	last := ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var record auditRecord
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return "", err
+		}
+		last = record.Hash
+	}
+
+	return last, scanner.Err()
+}
+
+// writeAudit appends the formatted entry to the audit log, if the audit chain is enabled in s.
+func writeAudit(s settings, entry string) {
+	if !s.auditChain {
+		return
+	}
+
+	reconfigureMu.RLock()
+	path := LogDir + "/audit.log"
+	reconfigureMu.RUnlock()
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if path != auditPath {
+		// continue the chain of an existing audit log
+		last, err := lastAuditHash(path)
+		if err != nil {
+			reportError(fmt.Errorf("could not read audit log: %w", err))
+			return
+		}
+		auditPath = path
+		auditLastHash = last
+	}
+
+	record := newAuditRecord(auditLastHash, entry)
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		reportError(fmt.Errorf("could not encode audit record: %w", err))
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		reportError(fmt.Errorf("could not open audit log: %w", err))
+		return
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		reportError(fmt.Errorf("could not write audit log: %w", err))
+		return
+	}
+
+	auditLastHash = record.Hash
+}
+
+// VerifyAuditChain checks the integrity of the audit log at the given path.
+// It returns an error naming the first line whose hash doesn't match its content or doesn't chain
+// to the previous line, and nil if the whole chain is intact.
+func VerifyAuditChain(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	prevHash := ""
+	lineNumber := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lineNumber++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var record auditRecord
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return fmt.Errorf("audit log line %d is malformed: %w", lineNumber, err)
+		}
+		if record.Entry != "" && record.EntryBase64 != nil {
+			return fmt.Errorf("audit log line %d is malformed: both entry and entry_base64 are set", lineNumber)
+		}
+
+		if record.PrevHash != prevHash {
+			return fmt.Errorf("audit log line %d doesn't chain to the previous line", lineNumber)
+		}
+
+		if record.Hash != auditHash(record.PrevHash, record.hashedEntry()) {
+			return fmt.Errorf("audit log line %d doesn't match its hash", lineNumber)
+		}
+
+		prevHash = record.Hash
+	}
+
+	return scanner.Err()
+}
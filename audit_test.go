@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditChain(t *testing.T) {
+	_, dir := captureOutputAndDir(t)
+
+	SetAuditChain(true)
+	t.Cleanup(func() {
+		SetAuditChain(false)
+	})
+
+	Info("user created")
+	Warning("password changed")
+	Error("login failed")
+
+	path := filepath.Join(dir, "audit.log")
+	err := VerifyAuditChain(path)
+	if err != nil {
+		t.Fatalf("expected an intact chain, got %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(b), "password changed", "password kept", 1)
+	err = os.WriteFile(path, []byte(tampered), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = VerifyAuditChain(path)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected the tampered line 2 to be detected, got %v", err)
+	}
+}
+
+func TestAuditChainWithBinaryEntries(t *testing.T) {
+	_, dir := captureOutputAndDir(t)
+
+	SetAuditChain(true)
+	t.Cleanup(func() {
+		SetAuditChain(false)
+	})
+
+	Error("bad \xff bytes")
+	useOutputFormat(t, FormatProtobuf)
+	Info("protobuf entry")
+	Warning("protobuf entry with invalid \xfe bytes")
+
+	path := filepath.Join(dir, "audit.log")
+	err := VerifyAuditChain(path)
+	if err != nil {
+		t.Fatalf("expected an intact chain, got %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := nonEmptyLines(string(b))
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 audit records, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, `"entry_base64"`) {
+			t.Errorf("expected record %d to be stored as base64, got %s", i+1, line)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var dailyByteBudget int64
+var budgetMu sync.Mutex
+var budgetDate = ""
+var budgetUsed int64
+var budgetExceeded = false
+var budgetDropped atomic.Uint64
+
+// SetDailyByteBudget limits the number of bytes written to the main log per day, e.g. when the logs are
+// shipped to a backend that charges by volume. Once the budget is used up, entries below WARNING are
+// dropped for the rest of the day and a single warning is logged; WARNING and more severe entries are
+// always written. The budget resets at midnight. A value of 0 disables the budget, which is the default.
+func SetDailyByteBudget(n int64) {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+
+	dailyByteBudget = n
+}
+
+// budgetAllows accounts an entry of the given level and size logged at t against the daily byte budget.
+// It returns whether the entry may be written, whether the budget was exceeded for the first time today and
+// the budget it was checked against.
+func budgetAllows(level string, t time.Time, size int) (allowed bool, exceeded bool, limit int64) {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+
+	limit = dailyByteBudget
+	if limit <= 0 {
+		return true, false, limit
+	}
+
+	// reset the budget at day rollover
+	date := t.Format("2006-01-02")
+	if date != budgetDate {
+		budgetDate = date
+		budgetUsed = 0
+		budgetExceeded = false
+	}
+
+	critical := LevelWeights[level] >= LevelWeights[LevelWarning]
+	if !critical && budgetUsed+int64(size) > limit {
+		budgetDropped.Add(1)
+		if !budgetExceeded {
+			budgetExceeded = true
+			return false, true, limit
+		}
+
+		return false, false, limit
+	}
+
+	budgetUsed += int64(size)
+	return true, false, limit
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// useDailyByteBudget sets a fresh daily byte budget for the duration of the test.
+func useDailyByteBudget(t *testing.T, n int64) {
+	t.Helper()
+
+	resetBudget := func() {
+		budgetMu.Lock()
+		budgetDate = ""
+		budgetMu.Unlock()
+	}
+
+	resetBudget()
+	SetDailyByteBudget(n)
+	t.Cleanup(func() {
+		SetDailyByteBudget(0)
+		resetBudget()
+	})
+}
+
+func TestDailyByteBudget(t *testing.T) {
+	buf := captureOutput(t)
+	useDailyByteBudget(t, 200)
+
+	dropped := GetMetrics().DroppedBudget
+	Info("within budget " + strings.Repeat("x", 100))
+	Info("over budget " + strings.Repeat("x", 100))
+	Info("still over budget")
+	Error("critical entry " + strings.Repeat("x", 100))
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 entries, got %q", lines)
+	}
+	if !strings.Contains(lines[0], "within budget") {
+		t.Errorf("expected the first entry to be written, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "WARNING Daily log byte budget of 200 bytes exceeded") {
+		t.Errorf("expected a single warning, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "ERROR critical entry") {
+		t.Errorf("expected the ERROR entry to pass, got %q", lines[2])
+	}
+	if GetMetrics().DroppedBudget-dropped != 2 {
+		t.Errorf("expected 2 dropped entries, got %d", GetMetrics().DroppedBudget-dropped)
+	}
+}
+
+func TestDailyByteBudgetWhileLogging(t *testing.T) {
+	buf := captureOutput(t)
+	useDailyByteBudget(t, 100)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			SetDailyByteBudget(int64(100 + i%2))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			// start a new day, so every entry exceeds the budget again
+			budgetMu.Lock()
+			budgetDate = ""
+			budgetMu.Unlock()
+
+			Info("entry " + strings.Repeat("x", 100))
+		}
+	}()
+	wg.Wait()
+
+	if n := strings.Count(buf.String(), "Daily log byte budget of"); n != 100 {
+		t.Errorf("expected a warning per entry, got %d", n)
+	}
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// BufferedWriter buffers the entries written to an output and writes them in batches, either when the buffer
+// is full or periodically. It lets every output have its own buffering, e.g. a network sink can batch
+// aggressively with a large buffer while a local file is flushed promptly.
+type BufferedWriter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	w        *bufio.Writer
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewBufferedWriter returns a writer that buffers up to size bytes before writing them to w and flushes the
+// buffer every flushInterval. A size of 0 uses a 4 KB buffer, a flushInterval of 0 disables the periodic flush.
+// Call Close to stop the periodic flush and write the remaining entries.
+func NewBufferedWriter(w io.Writer, size int, flushInterval time.Duration) *BufferedWriter {
+	if size <= 0 {
+		size = 4096
+	}
+
+	b := &BufferedWriter{
+		out:  w,
+		w:    bufio.NewWriterSize(w, size),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if flushInterval > 0 {
+		go b.flushPeriodically(flushInterval)
+	} else {
+		close(b.done)
+	}
+
+	return b
+}
+
+// Write adds p to the buffer, writing the buffer to the underlying writer first if p doesn't fit.
+// If writing to the underlying writer fails, the buffered entries are dropped and the error is returned,
+// so the writer recovers once the underlying writer works again.
+func (b *BufferedWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n, err := b.w.Write(p)
+	if err != nil {
+		b.w.Reset(b.out)
+	}
+
+	return n, err
+}
+
+// Flush writes the buffered entries to the underlying writer.
+// If it fails, the buffered entries are dropped and the error is returned, see Write.
+func (b *BufferedWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.w.Flush()
+	if err != nil {
+		b.w.Reset(b.out)
+	}
+
+	return err
+}
+
+// Close stops the periodic flush and writes the remaining entries. It doesn't close the underlying writer.
+func (b *BufferedWriter) Close() error {
+	b.stopOnce.Do(func() { close(b.stop) })
+	<-b.done
+
+	return b.Flush()
+}
+
+// flushPeriodically flushes the buffer every interval until the writer is closed.
+func (b *BufferedWriter) flushPeriodically(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			err := b.Flush()
+			if err != nil {
+				reportError(fmt.Errorf("could not flush buffered output: %w", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that can be read while the periodic flush writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestBufferedOutputs(t *testing.T) {
+	setupLogDir(t)
+
+	var file, network syncBuffer
+	fileSink := NewBufferedWriter(&file, 0, 10*time.Millisecond)
+	networkSink := NewBufferedWriter(&network, 64*1024, 0)
+	AddSink(fileSink)
+	AddSink(networkSink)
+	t.Cleanup(func() {
+		RemoveSink(fileSink)
+		RemoveSink(networkSink)
+		fileSink.Close()
+	})
+
+	for i := 0; i < 10; i++ {
+		Info("buffered entry")
+	}
+
+	// the file is flushed promptly, the network batch isn't full yet
+	deadline := time.Now().Add(5 * time.Second)
+	for strings.Count(file.String(), "buffered entry") < 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the file sink to be flushed, got %q", file.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if network.String() != "" {
+		t.Errorf("expected the network sink to hold back its batch, got %q", network.String())
+	}
+
+	err := networkSink.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(network.String(), "buffered entry") != 10 {
+		t.Errorf("expected the batch after closing the network sink, got %q", network.String())
+	}
+}
@@ -0,0 +1,295 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// limits of a single PutLogEvents call, see the CloudWatch Logs API reference
+const (
+	cloudWatchMaxBatchBytes  = 1048576
+	cloudWatchMaxBatchEvents = 10000
+	cloudWatchEventOverhead  = 26
+	cloudWatchMaxEventBytes  = 262144 - cloudWatchEventOverhead
+)
+
+// cloudWatchMaxPending is the maximum number of entries kept while CloudWatch is unreachable.
+// When it's reached, the oldest entries are dropped.
+const cloudWatchMaxPending = 100000
+
+var cloudWatchFlushInterval = time.Second
+var cloudWatchMaxBackoff = 30 * time.Second
+
+// CloudWatchEvent is a single log event of a PutLogEvents call.
+type CloudWatchEvent struct {
+	// Timestamp is the time of the event in milliseconds since the Unix epoch.
+	Timestamp int64
+
+	// Message is the formatted entry without the trailing newline.
+	Message string
+}
+
+// CloudWatchPutInput is the input of a PutLogEvents call.
+type CloudWatchPutInput struct {
+	LogGroupName  string
+	LogStreamName string
+	LogEvents     []CloudWatchEvent
+
+	// SequenceToken is the token returned by the previous call. It's empty for the first call.
+	SequenceToken string
+}
+
+// CloudWatchClient sends log events to CloudWatch Logs.
+// It's implemented by a thin wrapper around the PutLogEvents call of the AWS SDK, which keeps the SDK out of the
+// dependencies of applications that don't use CloudWatch.
+// PutLogEvents returns the next sequence token. If the sequence token was rejected, it should return a
+// *CloudWatchSequenceTokenError with the expected token, so the batch is resent with it.
+type CloudWatchClient interface {
+	PutLogEvents(input CloudWatchPutInput) (nextSequenceToken string, err error)
+}
+
+// CloudWatchSequenceTokenError is returned by a CloudWatchClient when the sequence token was rejected.
+type CloudWatchSequenceTokenError struct {
+	ExpectedSequenceToken string
+}
+
+func (e *CloudWatchSequenceTokenError) Error() string {
+	return "invalid CloudWatch sequence token, expected " + e.ExpectedSequenceToken
+}
+
+// cloudWatchSink collects the entries of the main log and sends them to CloudWatch in the background.
+type cloudWatchSink struct {
+	group  string
+	stream string
+
+	mu      sync.Mutex
+	pending []CloudWatchEvent
+	token   string
+	notify  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	handle  *output
+	dropped uint64
+
+	// droppedTotal counts all dropped entries, so flush knows how many of a sent batch are still pending
+	droppedTotal uint64
+}
+
+var cloudWatchMu sync.Mutex
+var cloudWatchClient CloudWatchClient
+var cloudWatch *cloudWatchSink
+
+// SetCloudWatchClient sets the client used to send entries to CloudWatch. See SetCloudWatch.
+func SetCloudWatchClient(client CloudWatchClient) {
+	cloudWatchMu.Lock()
+	cloudWatchClient = client
+	cloudWatchMu.Unlock()
+}
+
+// SetCloudWatch sends all entries of the main log to the given CloudWatch log group and stream in addition to
+// the log file. The entries are batched and sent in the background with the client set by SetCloudWatchClient,
+// so logging never waits for CloudWatch. Failed batches are retried with an increasing delay; while CloudWatch is
+// unreachable, up to 100000 entries are kept and the oldest ones are dropped after that.
+// Passing an empty group disables CloudWatch again after sending the entries collected so far.
+func SetCloudWatch(group string, stream string) {
+	cloudWatchMu.Lock()
+	previous := cloudWatch
+	cloudWatch = nil
+	cloudWatchMu.Unlock()
+
+	// stop the previous sink, sending what it collected
+	if previous != nil {
+		removeOutput(previous.handle)
+		close(previous.stop)
+		<-previous.done
+	}
+
+	if group == "" {
+		return
+	}
+
+	s := &cloudWatchSink{
+		group:  group,
+		stream: stream,
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	cloudWatchMu.Lock()
+	cloudWatch = s
+	cloudWatchMu.Unlock()
+
+	go s.run()
+	s.handle = addOutput(s)
+}
+
+// Write queues the entry for the next batch. It never blocks on the network.
+func (s *cloudWatchSink) Write(p []byte) (int, error) {
+	event := CloudWatchEvent{
+		Timestamp: time.Now().UnixMilli(),
+		Message:   strings.TrimSuffix(string(p), "\n"),
+	}
+
+	// CloudWatch rejects events larger than 256 KB, cut at the start of a rune to keep the message valid UTF-8
+	if len(event.Message) > cloudWatchMaxEventBytes {
+		end := cloudWatchMaxEventBytes
+		for end > 0 && !utf8.RuneStart(event.Message[end]) {
+			end--
+		}
+		event.Message = event.Message[:end]
+	}
+
+	s.mu.Lock()
+	if len(s.pending) >= cloudWatchMaxPending {
+		s.pending = s.pending[1:]
+		s.dropped++
+		s.droppedTotal++
+	}
+	s.pending = append(s.pending, event)
+	full := len(s.pending) >= cloudWatchMaxBatchEvents
+	s.mu.Unlock()
+
+	// send right away if a batch is full
+	if full {
+		select {
+		case s.notify <- struct{}{}:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+// run sends the pending entries periodically until the sink is stopped.
+func (s *cloudWatchSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(cloudWatchFlushInterval)
+	defer ticker.Stop()
+
+	backoff := time.Duration(0)
+	for {
+		select {
+		case <-s.stop:
+			// make a last attempt to send the remaining entries
+			s.flush()
+			return
+		case <-ticker.C:
+		case <-s.notify:
+		}
+
+		if backoff > 0 {
+			select {
+			case <-s.stop:
+				s.flush()
+				return
+			case <-time.After(backoff):
+			}
+		}
+
+		if s.flush() {
+			if backoff > 0 {
+				logLifecycleEvent(EventSinkReconnected, map[string]interface{}{"sink": "cloudwatch"})
+			}
+			backoff = 0
+		} else if backoff == 0 {
+			backoff = cloudWatchFlushInterval
+		} else if backoff < cloudWatchMaxBackoff {
+			backoff *= 2
+			if backoff > cloudWatchMaxBackoff {
+				backoff = cloudWatchMaxBackoff
+			}
+		}
+	}
+}
+
+// flush sends the pending entries in batches and reports whether all of them were sent.
+// A batch that fails stays pending, so it's retried by the next flush.
+func (s *cloudWatchSink) flush() bool {
+	cloudWatchMu.Lock()
+	client := cloudWatchClient
+	cloudWatchMu.Unlock()
+
+	s.mu.Lock()
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+
+	if dropped > 0 {
+		reportError(fmt.Errorf("dropped %d entries for CloudWatch because too many were pending", dropped))
+		logLifecycleEvent(EventBufferOverflowed, map[string]interface{}{"sink": "cloudwatch", "dropped": dropped})
+	}
+
+	for {
+		s.mu.Lock()
+		batch := cloudWatchBatch(s.pending)
+		token := s.token
+		droppedBefore := s.droppedTotal
+		s.mu.Unlock()
+
+		if len(batch) == 0 {
+			return true
+		}
+
+		if client == nil {
+			reportError(errors.New("could not send entries to CloudWatch: no client set"))
+			return false
+		}
+
+		input := CloudWatchPutInput{
+			LogGroupName:  s.group,
+			LogStreamName: s.stream,
+			LogEvents:     batch,
+			SequenceToken: token,
+		}
+		next, err := client.PutLogEvents(input)
+
+		// resend the batch with the expected sequence token
+		var tokenErr *CloudWatchSequenceTokenError
+		if errors.As(err, &tokenErr) {
+			input.SequenceToken = tokenErr.ExpectedSequenceToken
+			next, err = client.PutLogEvents(input)
+		}
+
+		if err != nil {
+			reportError(fmt.Errorf("could not send entries to CloudWatch: %w", err))
+			return false
+		}
+
+		// remove the sent entries; the oldest ones may have been dropped in the meantime
+		s.mu.Lock()
+		s.token = next
+		sent := len(batch) - int(s.droppedTotal-droppedBefore)
+		if sent > 0 {
+			s.pending = s.pending[sent:]
+		}
+		s.mu.Unlock()
+	}
+}
+
+// cloudWatchBatch returns the first events that fit into a single PutLogEvents call, sorted by their timestamp.
+// The timestamps are taken before the entries are queued, so concurrent writers can queue them slightly out of
+// order, which CloudWatch rejects.
+func cloudWatchBatch(events []CloudWatchEvent) []CloudWatchEvent {
+	n := len(events)
+	size := 0
+	for i, event := range events {
+		size += len(event.Message) + cloudWatchEventOverhead
+		if i == cloudWatchMaxBatchEvents || (size > cloudWatchMaxBatchBytes && i > 0) {
+			n = i
+			break
+		}
+	}
+
+	batch := append([]CloudWatchEvent(nil), events[:n]...)
+	sort.SliceStable(batch, func(i, j int) bool {
+		return batch[i].Timestamp < batch[j].Timestamp
+	})
+	return batch
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// mockCloudWatch records the PutLogEvents calls. If rejectToken is set, the first call is rejected with it as
+// expected sequence token.
+type mockCloudWatch struct {
+	mu          sync.Mutex
+	inputs      []CloudWatchPutInput
+	rejectToken string
+}
+
+func (m *mockCloudWatch) PutLogEvents(input CloudWatchPutInput) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rejectToken != "" && input.SequenceToken != m.rejectToken {
+		return "", &CloudWatchSequenceTokenError{ExpectedSequenceToken: m.rejectToken}
+	}
+
+	m.inputs = append(m.inputs, input)
+	return "next-token", nil
+}
+
+// useCloudWatch sends the main log to the given mock client until the test ends.
+func useCloudWatch(t *testing.T, client *mockCloudWatch) {
+	t.Helper()
+
+	SetCloudWatchClient(client)
+	SetCloudWatch("group", "stream")
+	t.Cleanup(func() {
+		SetCloudWatch("", "")
+		SetCloudWatchClient(nil)
+	})
+}
+
+func TestCloudWatch(t *testing.T) {
+	captureOutput(t)
+	client := &mockCloudWatch{rejectToken: "expected-token"}
+	useCloudWatch(t, client)
+
+	Info("first")
+	Info("second")
+	SetCloudWatch("", "")
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if len(client.inputs) != 1 {
+		t.Fatalf("expected a single batch, got %d", len(client.inputs))
+	}
+	input := client.inputs[0]
+	if input.LogGroupName != "group" || input.LogStreamName != "stream" || input.SequenceToken != "expected-token" {
+		t.Errorf("unexpected input %+v", input)
+	}
+	if len(input.LogEvents) != 2 || !strings.HasSuffix(input.LogEvents[0].Message, "INFO first") ||
+		!strings.HasSuffix(input.LogEvents[1].Message, "INFO second") {
+		t.Errorf("expected both entries in order without newline, got %+v", input.LogEvents)
+	}
+	if input.LogEvents[0].Timestamp == 0 {
+		t.Error("expected a timestamp")
+	}
+}
+
+func TestCloudWatchBatchIsSorted(t *testing.T) {
+	events := []CloudWatchEvent{{Timestamp: 3, Message: "c"}, {Timestamp: 1, Message: "a"}, {Timestamp: 2, Message: "b"}}
+
+	batch := cloudWatchBatch(events)
+	for i, expected := range []string{"a", "b", "c"} {
+		if batch[i].Message != expected {
+			t.Errorf("expected %s at %d, got %s", expected, i, batch[i].Message)
+		}
+	}
+	if events[0].Message != "c" {
+		t.Error("expected the pending events to stay unchanged")
+	}
+}
+
+func TestCloudWatchTruncatesAtRuneBoundary(t *testing.T) {
+	s := &cloudWatchSink{notify: make(chan struct{}, 1)}
+
+	// the 3 bytes of € don't end at the limit
+	s.Write([]byte("x" + strings.Repeat("€", cloudWatchMaxEventBytes/3+1)))
+
+	message := s.pending[0].Message
+	if len(message) > cloudWatchMaxEventBytes || len(message) < cloudWatchMaxEventBytes-3 || !utf8.ValidString(message) {
+		t.Errorf("expected a valid message of almost %d bytes, got %d bytes", cloudWatchMaxEventBytes, len(message))
+	}
+}
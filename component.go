@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var componentFilesMu sync.RWMutex
+var componentFiles = map[string]bool{}
+
+// componentLogFiles are the cached handles of the component files by component, guarded by writeMu.
+var componentLogFiles = map[string]logFile{}
+
+// SetComponentFile sets whether the entries of the given component are additionally written to their own
+// dated file LogDir/component-YYYY-MM-DD.log. The main log file still receives all entries.
+// This helps to follow a single workload of a process that handles several of them.
+// Components whose file would collide with the error log or the request logs, i.e. "errors" and the request log
+// prefix, are rejected with log.Println.
+func SetComponentFile(component string, enable bool) {
+	if enable && componentFileCollides(component) {
+		log.Println("LOGGER: Component file for " + component + " would collide with the error or request logs")
+		return
+	}
+
+	componentFilesMu.Lock()
+	defer componentFilesMu.Unlock()
+
+	if enable {
+		componentFiles[component] = true
+	} else {
+		delete(componentFiles, component)
+	}
+}
+
+// componentFileCollides reports whether the file of the component would have the name of another log file.
+func componentFileCollides(component string) bool {
+	name := componentFileName(component)
+	prefix := currentSettings().requestLogPrefix
+	return name == "errors" || name == prefix || name == prefix+"-simple"
+}
+
+// componentFileName returns the component with path separators replaced, so the file always ends up in LogDir.
+func componentFileName(component string) string {
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(component)
+}
+
+// componentLogFilename returns the name of the component file for the given component and time.
+func componentLogFilename(component string, t time.Time) string {
+	// format time to YYYY-MM-DD
+	return LogDir + "/" + componentFileName(component) + "-" + t.Format("2006-01-02") + ".log"
+}
+
+// writeComponentLog writes the entry to the file of its component, if enabled by SetComponentFile.
+// The file is kept open for the next entries of the component and reopened when the day changes.
+// writeMu must be held, so the returned error is passed to the error handler by the caller once it's released.
+func writeComponentLog(component string, t time.Time, entry string) error {
+	if component == "" {
+		return nil
+	}
+
+	componentFilesMu.RLock()
+	enabled := componentFiles[component]
+	componentFilesMu.RUnlock()
+
+	if !enabled {
+		return nil
+	}
+
+	err := ensureLogDir()
+	if err != nil {
+		return err
+	}
+
+	filename := componentLogFilename(component, t)
+	file := componentLogFiles[component]
+	if file.f == nil || file.name != filename {
+		closeComponentLogFile(component)
+
+		f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("could not open component log: %w", err)
+		}
+		file = logFile{f: f, name: filename}
+		componentLogFiles[component] = file
+	}
+
+	err = writeEntry(file.f, entry)
+	if errors.Is(err, errWriteTimeout) {
+		// the file is closed in the background, so it's reopened for the next entry
+		delete(componentLogFiles, component)
+		return fmt.Errorf("could not write component log: %w", err)
+	}
+	if err != nil {
+		closeComponentLogFile(component)
+		return fmt.Errorf("could not write component log: %w", err)
+	}
+
+	touchLogFiles()
+	return nil
+}
+
+// closeComponentLogFile closes the cached handle of the component file, if any. writeMu must be held.
+func closeComponentLogFile(component string) error {
+	file, ok := componentLogFiles[component]
+	if !ok {
+		return nil
+	}
+
+	delete(componentLogFiles, component)
+	return file.f.Close()
+}
+
+// closeComponentLogFiles closes the cached handles of all component files. writeMu must be held.
+func closeComponentLogFiles() error {
+	var firstErr error
+	for component := range componentLogFiles {
+		err := closeComponentLogFile(component)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestComponentFiles(t *testing.T) {
+	dir := setupLogDir(t)
+
+	SetComponentFile("billing", true)
+	SetComponentFile("mailer", true)
+	t.Cleanup(func() {
+		SetComponentFile("billing", false)
+		SetComponentFile("mailer", false)
+	})
+
+	Named("billing").Info("invoice sent")
+	Named("mailer").Info("mail queued")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	date := now().Format("2006-01-02")
+	expected := []string{date + ".log", "billing-" + date + ".log", "mailer-" + date + ".log"}
+	sort.Strings(expected)
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected the files %v, got %v", expected, names)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "billing-"+date+".log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "invoice sent") || strings.Contains(string(b), "mail queued") {
+		t.Errorf("expected only the entries of billing in its file, got %q", b)
+	}
+
+	if len(readMainLog(t, dir)) != 2 {
+		t.Error("expected the main log to receive both entries")
+	}
+}
+
+func TestComponentFileCollision(t *testing.T) {
+	SetComponentFile("errors", true)
+	SetComponentFile("requests", true)
+
+	componentFilesMu.RLock()
+	defer componentFilesMu.RUnlock()
+	if componentFiles["errors"] || componentFiles["requests"] {
+		t.Error("expected components colliding with other log files to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// CompressRotatedLogs sets whether the main log files of past days are compressed to YYYY-MM-DD.log.gz.
+// A rotated file is compressed with the next rotation instead of right away, i.e. once the first entry of the
+// day after the next day is written, so entries delayed past midnight still reach the uncompressed file.
+// Shutdown compresses the last rotated file. The compression runs in the background and the uncompressed
+// file is removed afterwards. Default: false
+// Assigning it is only safe before the first entry is logged, use SetCompressRotatedLogs afterwards.
+var CompressRotatedLogs = false
+
+// SetCompressRotatedLogs sets whether the main log file of the previous day is compressed, see CompressRotatedLogs.
+func SetCompressRotatedLogs(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	CompressRotatedLogs = enabled
+}
+
+// pendingCompression is the main log file rotated last, which is compressed with the next rotation,
+// guarded by pendingCompressionMu.
+var pendingCompressionMu sync.Mutex
+var pendingCompression = ""
+
+// compressRotatedLog keeps the rotated log file for the next rotation and compresses the one rotated before in
+// the background, if enabled in s.
+func compressRotatedLog(s settings, path string) {
+	if !s.compressRotatedLogs {
+		return
+	}
+
+	pendingCompressionMu.Lock()
+	previous := pendingCompression
+	pendingCompression = path
+	pendingCompressionMu.Unlock()
+
+	if previous == "" {
+		return
+	}
+
+	go func() {
+		err := compressLogFile(previous)
+		if err != nil {
+			reportError(fmt.Errorf("could not compress rotated log file: %w", err))
+		}
+	}()
+}
+
+// compressPendingLog compresses the main log file rotated last, if enabled. It's called by Shutdown, as no
+// delayed entries are written anymore.
+func compressPendingLog() error {
+	pendingCompressionMu.Lock()
+	path := pendingCompression
+	pendingCompression = ""
+	pendingCompressionMu.Unlock()
+
+	if path == "" || !currentSettings().compressRotatedLogs {
+		return nil
+	}
+
+	err := compressLogFile(path)
+	if err != nil {
+		return fmt.Errorf("could not compress rotated log file: %w", err)
+	}
+
+	return nil
+}
+
+// compressLogFile compresses the file at the given path to path.gz and removes the original.
+// An existing compressed file is never overwritten.
+func compressLogFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	// remove the incomplete compressed file on failure
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+
+	closeErr := dst.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	_ = src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForCompression waits until the file at path was compressed in the background and returns the content
+// of path.gz.
+func waitForCompression(t *testing.T, path string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s to be removed, got %v", path, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f, err := os.Open(path + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
+
+func TestCompressRotatedLogs(t *testing.T) {
+	dir := setupLogDir(t)
+	SetCompressRotatedLogs(true)
+	t.Cleanup(func() {
+		SetCompressRotatedLogs(false)
+		pendingCompressionMu.Lock()
+		pendingCompression = ""
+		pendingCompressionMu.Unlock()
+	})
+
+	// the first rotation keeps the file uncompressed for delayed entries
+	older := filepath.Join(dir, now().AddDate(0, 0, -2).Format("2006-01-02")+".log")
+	err := os.WriteFile(older, []byte("[two days ago] INFO entry\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeMu.Lock()
+	lastEntryDate = now().AddDate(0, 0, -2).Format("2006-01-02")
+	lastEntryFilename = older
+	writeMu.Unlock()
+
+	Info("first entry after two days")
+
+	if _, err := os.Stat(older); err != nil {
+		t.Fatalf("expected %s to be kept uncompressed until the next rotation, got %v", older, err)
+	}
+
+	// the next rotation compresses it
+	rotated := simulateDayChange(t, dir)
+	original, err := os.ReadFile(rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Info("first entry of the day")
+
+	content := waitForCompression(t, older)
+	if content != "[two days ago] INFO entry\n" {
+		t.Errorf("expected the original content, got %q", content)
+	}
+	if _, err := os.Stat(rotated); err != nil {
+		t.Fatalf("expected %s to be kept uncompressed until the next rotation, got %v", rotated, err)
+	}
+
+	// the last rotated file is compressed on shutdown
+	err = Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	content = waitForCompression(t, rotated)
+	if content != string(original) {
+		t.Errorf("expected the original content %q, got %q", original, content)
+	}
+}
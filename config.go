@@ -0,0 +1,342 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/oschwald/geoip2-golang"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// configMu guards the settings that are read while an entry is written: the minimum log level, the component,
+// the output format, the options of the entries like SetIncludeRuntime or SetSanitizeUTF8, the options of the
+// file writes like SetWriteTimeout or SetFilenameFunc and the options of the request log, the error log, the
+// retention and the other destinations like SetRequestLogDir, SetErrorLogSeparate or SetRemoteEndpoint.
+// Together with the locks of the remaining settings, like budgetMu or asyncLevelsMu, this makes the Set
+// functions of the package safe to call while other goroutines are logging, and every entry is written with a
+// consistent snapshot of the settings. The exported variables like Component, IncludeRuntime and OutputFormat
+// are guarded as well, but assigning them directly bypasses the lock, so that's only safe before the first
+// entry is logged. The same applies to the exported variables without a Set function, like FatalPanics.
+var configMu sync.RWMutex
+
+// settings is a snapshot of the settings guarded by configMu.
+type settings struct {
+	includeRuntime          bool
+	includeStep             bool
+	includeLoggerVersion    bool
+	includeEpochMillis      bool
+	includeFingerprint      bool
+	includeGoroutineID      bool
+	includeBuildInfo        bool
+	buildInfoCommit         string
+	buildInfoTime           string
+	sanitizeUTF8            bool
+	fatalAsError            bool
+	fatalExitCode           int
+	outputFormat            string
+	writeTimeout            time.Duration
+	fileLocking             bool
+	filenameFunc            func(t time.Time, level string) string
+	logRequestsSeparately   bool
+	hideRequestsFromMainLog bool
+	nestFields              bool
+	maskURLCredentials      bool
+	stdStreams              bool
+	lifecycleEvents         bool
+	auditChain              bool
+	requestLogLevel         string
+	requestLogDir           string
+	requestLogPrefix        string
+	includeClientLocalTime  bool
+	includeRequestGeo       bool
+	geoIPDB                 *geoip2.Reader
+	maxRequestLogSize       int64
+	maxRequestLogSegments   int
+	errorLogSeparate        bool
+	errorLogExclusive       bool
+	maxLogAgeDays           int
+	autoPruneOldLogs        bool
+	compressRotatedLogs     bool
+	remoteEndpoint          string
+	strictStartup           bool
+}
+
+// currentSettings returns a snapshot of the settings guarded by configMu.
+func currentSettings() settings {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return settings{
+		includeRuntime:          IncludeRuntime,
+		includeStep:             IncludeStep,
+		includeLoggerVersion:    includeLoggerVersion,
+		includeEpochMillis:      includeEpochMillis,
+		includeFingerprint:      includeFingerprint,
+		includeGoroutineID:      includeGoroutineID,
+		includeBuildInfo:        includeBuildInfo,
+		buildInfoCommit:         buildInfoCommit,
+		buildInfoTime:           buildInfoTime,
+		sanitizeUTF8:            sanitizeUTF8,
+		fatalAsError:            fatalAsError,
+		fatalExitCode:           fatalExitCode,
+		outputFormat:            OutputFormat,
+		writeTimeout:            writeTimeout,
+		fileLocking:             fileLocking,
+		filenameFunc:            filenameFunc,
+		logRequestsSeparately:   LogRequestsSeparately,
+		hideRequestsFromMainLog: HideRequestsFromMainLog,
+		nestFields:              nestFields,
+		maskURLCredentials:      maskCredentials,
+		stdStreams:              stdStreams,
+		lifecycleEvents:         lifecycleEvents,
+		auditChain:              auditChain,
+		requestLogLevel:         requestLogLevel,
+		requestLogDir:           requestLogDir,
+		requestLogPrefix:        requestLogPrefix,
+		includeClientLocalTime:  includeClientLocalTime,
+		includeRequestGeo:       includeRequestGeo,
+		geoIPDB:                 GeoIPDB,
+		maxRequestLogSize:       MaxRequestLogSize,
+		maxRequestLogSegments:   MaxRequestLogSegments,
+		errorLogSeparate:        ErrorLogSeparate,
+		errorLogExclusive:       ErrorLogExclusive,
+		maxLogAgeDays:           MaxLogAgeDays,
+		autoPruneOldLogs:        AutoPruneOldLogs,
+		compressRotatedLogs:     CompressRotatedLogs,
+		remoteEndpoint:          RemoteEndpoint,
+		strictStartup:           strictStartup,
+	}
+}
+
+// globalLevelWeight returns the weight of the minimum log level set by SetMinimumLogLevel.
+func globalLevelWeight() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return levelWeight
+}
+
+// Config is a snapshot of the settings of the logger, see EffectiveConfig.
+// Settings that can't be represented as JSON, like functions, writers and the GeoIP database, are not included.
+type Config struct {
+	LogDir                  string        `json:"log_dir"`
+	MinimumLogLevel         string        `json:"minimum_log_level"`
+	Component               string        `json:"component"`
+	OutputFormat            string        `json:"output_format"`
+	NestFields              bool          `json:"nest_fields"`
+	TimeLayout              string        `json:"time_layout"`
+	TimeZone                string        `json:"time_zone"`
+	IncludeRuntime          bool          `json:"include_runtime"`
+	IncludeStep             bool          `json:"include_step"`
+	IncludeLoggerVersion    bool          `json:"include_logger_version"`
+	IncludeEpochMillis      bool          `json:"include_epoch_millis"`
+	IncludeFingerprint      bool          `json:"include_fingerprint"`
+	IncludeGoroutineID      bool          `json:"include_goroutine_id"`
+	IncludeBuildInfo        bool          `json:"include_build_info"`
+	SanitizeUTF8            bool          `json:"sanitize_utf8"`
+	MaskURLCredentials      bool          `json:"mask_url_credentials"`
+	WriteTimeout            time.Duration `json:"write_timeout"`
+	FileLocking             bool          `json:"file_locking"`
+	FatalAsError            bool          `json:"fatal_as_error"`
+	FatalExitCode           int           `json:"fatal_exit_code"`
+	StdStreams              bool          `json:"std_streams"`
+	LifecycleEvents         bool          `json:"lifecycle_events"`
+	DailyByteBudget         int64         `json:"daily_byte_budget"`
+	MaxLogAgeDays           int           `json:"max_log_age_days"`
+	AutoPruneOldLogs        bool          `json:"auto_prune_old_logs"`
+	CompressRotatedLogs     bool          `json:"compress_rotated_logs"`
+	ErrorLogSeparate        bool          `json:"error_log_separate"`
+	ErrorLogExclusive       bool          `json:"error_log_exclusive"`
+	LogRequestsSeparately   bool          `json:"log_requests_separately"`
+	HideRequestsFromMainLog bool          `json:"hide_requests_from_main_log"`
+	RequestLogLevel         string        `json:"request_log_level"`
+	RequestLogDir           string        `json:"request_log_dir"`
+	RequestLogPrefix        string        `json:"request_log_prefix"`
+	MaxRequestLogSize       int64         `json:"max_request_log_size"`
+	MaxRequestLogSegments   int           `json:"max_request_log_segments"`
+	RemoteEndpoint          string        `json:"remote_endpoint"`
+}
+
+// EffectiveConfig returns the current settings of the logger, including the ones read from the environment
+// variables and changed by the Set functions.
+func EffectiveConfig() Config {
+	budgetMu.Lock()
+	budget := dailyByteBudget
+	budgetMu.Unlock()
+
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return Config{
+		LogDir:                  LogDir,
+		MinimumLogLevel:         minimumLogLevel,
+		Component:               Component,
+		OutputFormat:            OutputFormat,
+		NestFields:              nestFields,
+		TimeLayout:              TimeLayout,
+		TimeZone:                timeZoneName(),
+		IncludeRuntime:          IncludeRuntime,
+		IncludeStep:             IncludeStep,
+		IncludeLoggerVersion:    includeLoggerVersion,
+		IncludeEpochMillis:      includeEpochMillis,
+		IncludeFingerprint:      includeFingerprint,
+		IncludeGoroutineID:      includeGoroutineID,
+		IncludeBuildInfo:        includeBuildInfo,
+		SanitizeUTF8:            sanitizeUTF8,
+		MaskURLCredentials:      maskCredentials,
+		WriteTimeout:            writeTimeout,
+		FileLocking:             fileLocking,
+		FatalAsError:            fatalAsError,
+		FatalExitCode:           fatalExitCode,
+		StdStreams:              stdStreams,
+		LifecycleEvents:         lifecycleEvents,
+		DailyByteBudget:         budget,
+		MaxLogAgeDays:           MaxLogAgeDays,
+		AutoPruneOldLogs:        AutoPruneOldLogs,
+		CompressRotatedLogs:     CompressRotatedLogs,
+		ErrorLogSeparate:        ErrorLogSeparate,
+		ErrorLogExclusive:       ErrorLogExclusive,
+		LogRequestsSeparately:   LogRequestsSeparately,
+		HideRequestsFromMainLog: HideRequestsFromMainLog,
+		RequestLogLevel:         requestLogLevel,
+		RequestLogDir:           requestLogDir,
+		RequestLogPrefix:        requestLogPrefix,
+		MaxRequestLogSize:       MaxRequestLogSize,
+		MaxRequestLogSegments:   MaxRequestLogSegments,
+		RemoteEndpoint:          RemoteEndpoint,
+	}
+}
+
+// SaveConfig writes the EffectiveConfig as JSON to the file at the given path, so the settings of a running
+// application can be captured and restored with LoadConfigFile.
+func SaveConfig(path string) error {
+	data, err := json.MarshalIndent(EffectiveConfig(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadConfigFile reads a JSON config as written by SaveConfig from the file at the given path and applies it.
+// Settings missing in the file keep their current values. If the config is invalid, nothing is applied.
+func LoadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	config := EffectiveConfig()
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return err
+	}
+
+	return applyConfig(config)
+}
+
+// timeZoneName returns the name of TimeZone, or an empty string if the local time zone is used.
+// Zones that can't be loaded by name, like the ones created by time.FixedZone, are described by their offset,
+// e.g. "+02:00", so loadTimeZone can restore them. configMu must be held.
+func timeZoneName() string {
+	if TimeZone == nil {
+		return ""
+	}
+
+	name := TimeZone.String()
+	if name != "" {
+		_, err := time.LoadLocation(name)
+		if err == nil {
+			return name
+		}
+	}
+
+	return time.Now().In(TimeZone).Format("-07:00")
+}
+
+// loadTimeZone returns the location with the given name as returned by timeZoneName, which is either the name
+// of a location in the time zone database or an offset like "+02:00".
+func loadTimeZone(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err == nil {
+		return loc, nil
+	}
+
+	t, offsetErr := time.Parse("-07:00", name)
+	if offsetErr != nil {
+		return nil, err
+	}
+
+	_, offset := t.Zone()
+	return time.FixedZone(name, offset), nil
+}
+
+// applyConfig validates the config and applies it.
+func applyConfig(config Config) error {
+	config.MinimumLogLevel = strings.ToUpper(strings.TrimSpace(config.MinimumLogLevel))
+	config.OutputFormat = strings.ToLower(strings.TrimSpace(config.OutputFormat))
+
+	if !IsValidLevel(config.MinimumLogLevel) {
+		return errors.New("invalid logger configuration: unknown minimum log level " + config.MinimumLogLevel)
+	}
+	if !IsValidLevel(config.RequestLogLevel) {
+		return errors.New("invalid logger configuration: unknown request log level " + config.RequestLogLevel)
+	}
+	if !isValidFormat(config.OutputFormat) {
+		return errors.New("invalid logger configuration: unknown output format " + config.OutputFormat)
+	}
+
+	var timeZone *time.Location
+	if config.TimeZone != "" {
+		var err error
+		timeZone, err = loadTimeZone(config.TimeZone)
+		if err != nil {
+			return errors.New("invalid logger configuration: unknown time zone " + config.TimeZone)
+		}
+	}
+
+	err := SetLogDir(config.LogDir)
+	if err != nil {
+		return err
+	}
+
+	SetMinimumLogLevel(config.MinimumLogLevel)
+	SetComponent(config.Component)
+	SetOutputFormat(config.OutputFormat)
+	SetNestFields(config.NestFields)
+	SetTimeLayout(config.TimeLayout)
+	SetTimeZone(timeZone)
+	SetIncludeRuntime(config.IncludeRuntime)
+	SetIncludeStep(config.IncludeStep)
+	SetIncludeLoggerVersion(config.IncludeLoggerVersion)
+	SetIncludeEpochMillis(config.IncludeEpochMillis)
+	SetIncludeFingerprint(config.IncludeFingerprint)
+	SetIncludeGoroutineID(config.IncludeGoroutineID)
+	SetIncludeBuildInfo(config.IncludeBuildInfo)
+	SetSanitizeUTF8(config.SanitizeUTF8)
+	SetMaskURLCredentials(config.MaskURLCredentials)
+	SetWriteTimeout(config.WriteTimeout)
+	SetFileLocking(config.FileLocking)
+	SetFatalAsError(config.FatalAsError)
+	SetFatalExitCode(config.FatalExitCode)
+	SetStdStreams(config.StdStreams)
+	SetLifecycleEvents(config.LifecycleEvents)
+	SetDailyByteBudget(config.DailyByteBudget)
+	SetMaxLogAgeDays(config.MaxLogAgeDays)
+	SetAutoPruneOldLogs(config.AutoPruneOldLogs)
+	SetCompressRotatedLogs(config.CompressRotatedLogs)
+	SetErrorLogSeparate(config.ErrorLogSeparate)
+	SetErrorLogExclusive(config.ErrorLogExclusive)
+	SetLogRequestsSeparately(config.LogRequestsSeparately)
+	SetHideRequestsFromMainLog(config.HideRequestsFromMainLog)
+	SetRequestLogLevel(config.RequestLogLevel)
+	SetRequestLogDir(config.RequestLogDir)
+	SetRequestLogPrefix(config.RequestLogPrefix)
+	SetMaxRequestLogSize(config.MaxRequestLogSize)
+	SetMaxRequestLogSegments(config.MaxRequestLogSegments)
+	SetRemoteEndpoint(config.RemoteEndpoint)
+
+	return nil
+}
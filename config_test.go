@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveConfigRoundTrip(t *testing.T) {
+	dir := setupLogDir(t)
+	preserveConfig(t)
+	original := EffectiveConfig()
+
+	changed := original
+	changed.MinimumLogLevel = LevelWarning
+	changed.Component = "api"
+	changed.OutputFormat = FormatLogfmt
+	changed.TimeZone = "+02:00"
+	changed.IncludeRuntime = true
+	changed.WriteTimeout = 3 * time.Second
+	changed.DailyByteBudget = 1 << 20
+	changed.MaxLogAgeDays = 14
+	changed.RequestLogPrefix = "access"
+	err := applyConfig(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := EffectiveConfig()
+
+	path := filepath.Join(dir, "config.json")
+	err = SaveConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = applyConfig(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = LoadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded := EffectiveConfig(); loaded != saved {
+		t.Errorf("expected the saved config after loading it\nsaved:  %+v\nloaded: %+v", saved, loaded)
+	}
+	if saved.TimeZone != "+02:00" {
+		t.Errorf("expected the fixed zone to be saved as its offset, got %q", saved.TimeZone)
+	}
+}
@@ -0,0 +1,61 @@
+package logger
+
+import "context"
+
+// contextKey is the type of the keys of the values stored in a context by this package.
+type contextKey int
+
+// keys of the values stored in a context, see ContextWithRequestID and ContextWithTraceID
+const (
+	contextKeyRequestID contextKey = iota
+	contextKeyTraceID
+)
+
+// field keys of the values taken from a context
+const (
+	keyRequestID = "request_id"
+	keyTraceID   = "trace_id"
+)
+
+// ContextWithRequestID returns a copy of ctx carrying the given request ID, which LogCtx adds to the entry.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKeyRequestID, id)
+}
+
+// ContextWithTraceID returns a copy of ctx carrying the given trace ID, which LogCtx adds to the entry.
+func ContextWithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKeyTraceID, id)
+}
+
+// LogCtx logs a message with the given log level like Log and adds the request ID and trace ID carried by ctx
+// as the fields request_id and trace_id, see LogFields. If ctx carries neither of them, the entry is the same
+// as the one of Log.
+func LogCtx(ctx context.Context, level string, content string) {
+	fields := contextFields(ctx)
+	if len(fields) == 0 {
+		dispatch(level, content)
+		return
+	}
+
+	std.dispatchFields(level, content, fields)
+}
+
+// contextFields returns the values carried by ctx as fields, or nil if there are none.
+func contextFields(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if id, ok := ctx.Value(contextKeyRequestID).(string); ok && id != "" {
+		fields = map[string]interface{}{keyRequestID: id}
+	}
+	if id, ok := ctx.Value(contextKeyTraceID).(string); ok && id != "" {
+		if fields == nil {
+			fields = map[string]interface{}{}
+		}
+		fields[keyTraceID] = id
+	}
+
+	return fields
+}
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLogCtx(t *testing.T) {
+	buf := captureOutput(t)
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	LogCtx(ctx, LevelInfo, "with request ID")
+	LogCtx(ContextWithTraceID(ctx, "trace 2"), LevelInfo, "with both")
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	if !strings.HasSuffix(lines[0], "INFO with request ID request_id=req-1") {
+		t.Errorf("expected the request ID, got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `INFO with both request_id=req-1 trace_id="trace 2"`) {
+		t.Errorf("expected both IDs, got %q", lines[1])
+	}
+}
+
+func TestLogCtxWithoutIDs(t *testing.T) {
+	buf := captureOutput(t)
+
+	LogCtx(context.Background(), LevelInfo, "plain")
+	LogCtx(ContextWithRequestID(context.Background(), ""), LevelInfo, "plain")
+	Log(LevelInfo, "plain")
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", lines)
+	}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, "] INFO plain") {
+			t.Errorf("expected the same entry as Log, got %q", line)
+		}
+	}
+}
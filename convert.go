@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"time"
+)
+
+// ConvertRequestCSV converts a request log in CSV format to JSON lines, one Request per line.
+// The columns are taken from the header row if present, so files written by older versions with fewer
+// columns are converted as well; without a header, the columns of GetCSVHeader are assumed.
+// Malformed rows are skipped and their number is logged.
+func ConvertRequestCSV(in io.Reader, out io.Writer) error {
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	encoder := json.NewEncoder(out)
+	columns := GetCSVHeader()
+	skipped := 0
+	first := true
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				skipped++
+				continue
+			}
+			return err
+		}
+
+		if first {
+			first = false
+			if len(record) > 0 && record[0] == columns[0] {
+				columns = record
+				continue
+			}
+		}
+
+		req, ok := requestFromCSVRecord(columns, record)
+		if !ok {
+			skipped++
+			continue
+		}
+
+		err = encoder.Encode(req)
+		if err != nil {
+			return err
+		}
+	}
+
+	if skipped > 0 {
+		log.Printf("LOGGER: Skipped %d malformed rows while converting request log\n", skipped)
+	}
+
+	return nil
+}
+
+// requestFromCSVRecord creates a Request from a CSV record with the given columns.
+// It returns false if the record doesn't match the columns or a numeric value can't be parsed.
+func requestFromCSVRecord(columns []string, record []string) (*Request, bool) {
+	if len(record) != len(columns) {
+		return nil, false
+	}
+
+	req := New()
+	for i, column := range columns {
+		value := record[i]
+
+		var err error
+		switch column {
+		case "connection_time":
+			req.ConnectionTime = value
+		case "method":
+			req.Method = value
+		case "path":
+			req.Path = value
+		case "ip":
+			req.IP = value
+		case "address":
+			req.Address = value
+		case "user_agent":
+			req.UserAgent = value
+		case "referer":
+			req.Referer = value
+		case "requested_host":
+			req.RequestedHost = value
+		case "continent":
+			req.Continent = value
+		case "country":
+			req.Country = value
+		case "country_code":
+			req.CountryCode = value
+		case "city":
+			req.City = value
+		case "latitude":
+			req.Latitude, err = strconv.ParseFloat(value, 64)
+		case "longitude":
+			req.Longitude, err = strconv.ParseFloat(value, 64)
+		case "timezone":
+			req.Timezone = value
+		case "postal_code":
+			req.PostalCode = value
+		case "subdivision":
+			req.Subdivision = value
+		case "subdivision_code":
+			req.SubdivisionCode = value
+		case "connection_id":
+			req.ConnectionID, err = strconv.ParseUint(value, 10, 64)
+		case "connection_seq":
+			req.ConnectionSeq, err = strconv.ParseUint(value, 10, 64)
+		case "count":
+			req.Count, err = strconv.ParseUint(value, 10, 64)
+		case "client_local_time":
+			req.ClientLocalTime = value
+		case "status_code":
+			req.StatusCode, err = strconv.Atoi(value)
+		case "response_bytes":
+			req.ResponseBytes, err = strconv.ParseInt(value, 10, 64)
+		case "duration":
+			req.Duration, err = time.ParseDuration(value)
+		case "extra":
+			if value != "" {
+				err = json.Unmarshal([]byte(value), &req.Extra)
+			}
+		}
+
+		if err != nil {
+			return nil, false
+		}
+	}
+
+	return req, true
+}
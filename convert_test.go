@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConvertRequestCSV(t *testing.T) {
+	first := sampleRequest()
+	second := &Request{Method: "POST", Path: "/login", IP: "::1", Count: 3}
+
+	var in bytes.Buffer
+	w := csv.NewWriter(&in)
+	w.Write(GetCSVHeader())
+	w.Flush()
+	in.WriteString(first.ToCSV())
+	in.WriteString(second.ToCSV())
+	in.WriteString("malformed,row\n")
+
+	var out bytes.Buffer
+	err := ConvertRequestCSV(&in, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := nonEmptyLines(out.String())
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d", len(lines))
+	}
+	for i, expected := range []*Request{first, second} {
+		var actual Request
+		err := json.Unmarshal([]byte(lines[i]), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&actual, expected) {
+			t.Errorf("line %d: expected %+v, got %+v", i+1, expected, actual)
+		}
+	}
+}
+
+func TestConvertRequestCSVWithoutHeader(t *testing.T) {
+	var out bytes.Buffer
+	err := ConvertRequestCSV(strings.NewReader(sampleRequest().ToCSV()), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual Request
+	err = json.Unmarshal(out.Bytes(), &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&actual, sampleRequest()) {
+		t.Errorf("expected %+v, got %+v", sampleRequest(), actual)
+	}
+}
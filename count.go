@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+// LevelUnknown is the key used by CountByLevel for lines whose level couldn't be determined.
+const LevelUnknown = "UNKNOWN"
+
+// CountByLevel reads the log file at the given path and returns the number of entries per level.
+// Gzip-compressed files are detected and decompressed automatically. Lines in the text, logfmt and JSON
+// formats are supported; malformed lines and lines with an unknown level are counted as LevelUnknown.
+func CountByLevel(path string) (map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+
+	// gzip streams start with the magic bytes 0x1f 0x8b
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	counts := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		counts[parseLevel(line)]++
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// parseLevel returns the level of a formatted log line, or LevelUnknown if it can't be determined.
+func parseLevel(line string) string {
+	level := ""
+	if strings.HasPrefix(line, "[") {
+		// text format: [timestamp][...] LEVEL content
+		rest := line
+		for strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return LevelUnknown
+			}
+			rest = rest[end+1:]
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) > 0 {
+			level = fields[0]
+		}
+	} else if strings.HasPrefix(line, "{") {
+		// JSON format: {"ts":"...","level":"LEVEL",...}
+		var entry struct {
+			Level string `json:"level"`
+		}
+		if json.Unmarshal([]byte(line), &entry) != nil {
+			return LevelUnknown
+		}
+		level = entry.Level
+	} else {
+		// logfmt format: ts=... level=LEVEL ...
+		for _, pair := range strings.Fields(line) {
+			if strings.HasPrefix(pair, keyLevel+"=") {
+				level = strings.Trim(strings.TrimPrefix(pair, keyLevel+"="), `"`)
+				break
+			}
+		}
+	}
+
+	if _, ok := LevelWeights[level]; !ok {
+		return LevelUnknown
+	}
+
+	return level
+}
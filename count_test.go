@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const seededLog = `[2023-01-01 10:00:00.000000] INFO server started
+[2023-01-01 10:00:01.000000][00:00:00:01.000000][api] WARNING slow request
+ts=2023-01-01T10:00:02.000000Z level=ERROR msg="query failed"
+{"ts":"2023-01-01T10:00:03.000000Z","level":"ERROR","msg":"query failed"}
+{"ts":"2023-01-01T10:00:04.000000Z","level":"INFO","msg":"retrying"}
+
+this line is not an entry
+{"broken json
+`
+
+func TestCountByLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2023-01-01.log")
+	err := os.WriteFile(path, []byte(seededLog), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := CountByLevel(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{LevelInfo: 2, LevelWarning: 1, LevelError: 2, LevelUnknown: 2}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected %v, got %v", expected, counts)
+	}
+}
+
+func TestCountByLevelGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2023-01-01.log.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte(seededLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	f.Close()
+
+	counts, err := CountByLevel(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[LevelError] != 2 || counts[LevelInfo] != 2 {
+		t.Errorf("expected the compressed entries to be counted, got %v", counts)
+	}
+}
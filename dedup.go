@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// requestDedupMaxKeys bounds the number of distinct requests held back at the same time.
+// Once reached, further distinct requests are written immediately without deduplication.
+const requestDedupMaxKeys = 1024
+
+var requestDedupWindow time.Duration
+var requestDedupMu sync.Mutex
+var requestDedupPending = map[string]*Request{}
+
+// SetRequestDedupWindow enables the deduplication of requests in the separate request log.
+// Requests with the same IP, method and path within the given window are collapsed into a single row
+// whose count column holds the number of requests. The row is written once the window has passed.
+// A value of 0 disables the deduplication, which is the default.
+func SetRequestDedupWindow(d time.Duration) {
+	requestDedupMu.Lock()
+	defer requestDedupMu.Unlock()
+
+	requestDedupWindow = d
+}
+
+// FlushRequestDedup writes all requests that are currently held back by the deduplication.
+// It should be called before the application exits to not lose the pending rows.
+func FlushRequestDedup() {
+	requestDedupMu.Lock()
+	pending := requestDedupPending
+	requestDedupPending = map[string]*Request{}
+	requestDedupMu.Unlock()
+
+	for _, req := range pending {
+		writeRequestCSV(req)
+	}
+}
+
+// dedupRequest checks if the request repeats a pending one within the dedup window.
+// It returns true if the request was collapsed into or held back as a pending request,
+// and false if it should be written right away. A held back request is copied, so the caller keeps its own.
+func dedupRequest(req *Request) bool {
+	key := req.IP + "\x00" + req.Method + "\x00" + req.Path
+
+	requestDedupMu.Lock()
+	defer requestDedupMu.Unlock()
+
+	if requestDedupWindow <= 0 {
+		return false
+	}
+
+	if pending, ok := requestDedupPending[key]; ok {
+		pending.Count += req.Count
+		return true
+	}
+
+	if len(requestDedupPending) >= requestDedupMaxKeys {
+		return false
+	}
+
+	held := *req
+	requestDedupPending[key] = &held
+	time.AfterFunc(requestDedupWindow, func() {
+		flushRequestDedupKey(key)
+	})
+
+	return true
+}
+
+// flushRequestDedupKey writes the pending request with the given key, if any.
+func flushRequestDedupKey(key string) {
+	requestDedupMu.Lock()
+	req, ok := requestDedupPending[key]
+	delete(requestDedupPending, key)
+	requestDedupMu.Unlock()
+
+	if ok {
+		writeRequestCSV(req)
+	}
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRequestDedup(t *testing.T) {
+	dir := setupLogDir(t)
+	enableRequestLog(t)
+
+	SetRequestDedupWindow(time.Hour)
+	t.Cleanup(func() {
+		SetRequestDedupWindow(0)
+	})
+
+	for i := 0; i < 50; i++ {
+		req := &Request{Method: "GET", Path: "/dedup", IP: "127.0.0.1"}
+		LogRequest(req)
+		if req.Count != 1 {
+			t.Fatalf("expected the request of the caller to stay unchanged, got count %d", req.Count)
+		}
+	}
+	FlushRequestDedup()
+
+	records := readCSV(t, filepath.Join(dir, "requests-"+now().Format("2006-01-02")+".csv"))
+	if len(records) != 2 {
+		t.Fatalf("expected the header and a single row, got %d records", len(records))
+	}
+
+	count := records[1][indexOf(GetCSVHeader(), "count")]
+	if count != "50" {
+		t.Errorf("expected count 50, got %s", count)
+	}
+}
+
+// indexOf returns the index of the given value in values, or -1 if it's missing.
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
+}
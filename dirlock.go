@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// dirLockName is the name of the lock file in LogDir.
+const dirLockName = "logger.lock"
+
+var dirLockMu sync.Mutex
+
+// dirLockPath is the path of the lock file held by this process, empty if none is held.
+var dirLockPath = ""
+
+// dirLockPending is set if LOGGER_DIR_LOCK requested the lock, which is acquired with the first entry.
+var dirLockPending atomic.Bool
+
+// acquirePendingDirLock acquires the lock of the log directory if it was requested by LOGGER_DIR_LOCK and not
+// attempted yet. Failures are passed to the error handler. It must not be called while writeMu is held.
+func acquirePendingDirLock() {
+	if !dirLockPending.Load() || !dirLockPending.CompareAndSwap(true, false) {
+		return
+	}
+
+	err := AcquireDirLock()
+	if err != nil {
+		reportError(fmt.Errorf("could not acquire lock of log directory: %w", err))
+	}
+}
+
+// AcquireDirLock creates the lock file logger.lock containing the PID of this process in LogDir.
+// It detects the misconfiguration of two processes sharing a LogDir unintentionally: if the lock is already
+// held by another running process, a warning is logged, or an error is returned under strict startup.
+// A lock file left behind by a process that isn't running anymore is taken over.
+// Release the lock with ReleaseDirLock when the process ends.
+func AcquireDirLock() error {
+	dirLockMu.Lock()
+	defer dirLockMu.Unlock()
+
+	writeMu.Lock()
+	err := ensureLogDir()
+	writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(LogDir, dirLockName)
+	pid := os.Getpid()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		_, err = f.WriteString(strconv.Itoa(pid) + "\n")
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		dirLockPath = path
+		return nil
+	}
+
+	if !errors.Is(err, os.ErrExist) {
+		return err
+	}
+
+	// check who holds the existing lock
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	holder, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err == nil && holder == pid {
+		dirLockPath = path
+		return nil
+	}
+
+	if err == nil && processAlive(holder) {
+		err = fmt.Errorf("log directory %s is already used by process %d", LogDir, holder)
+		if currentSettings().strictStartup {
+			return err
+		}
+
+		log.Println("LOGGER: Warning: " + err.Error())
+		return nil
+	}
+
+	// take over the lock of a process that isn't running anymore
+	err = os.WriteFile(path, []byte(strconv.Itoa(pid)+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	dirLockPath = path
+	return nil
+}
+
+// ReleaseDirLock removes the lock file created by AcquireDirLock, if this process holds it.
+func ReleaseDirLock() error {
+	dirLockMu.Lock()
+	defer dirLockMu.Unlock()
+
+	if dirLockPath == "" {
+		return nil
+	}
+
+	err := os.Remove(dirLockPath)
+	dirLockPath = ""
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
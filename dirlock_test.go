@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// captureStdLog captures the messages the logger writes with the standard log package for the duration of
+// the test.
+func captureStdLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+	})
+
+	return &buf
+}
+
+// holdDirLock writes the lock file of dir as if the parent process, which is running, held it.
+func holdDirLock(t *testing.T, dir string) {
+	t.Helper()
+
+	err := os.WriteFile(filepath.Join(dir, dirLockName), []byte(strconv.Itoa(os.Getppid())+"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirLock(t *testing.T) {
+	dir := setupLogDir(t)
+	t.Cleanup(func() {
+		ReleaseDirLock()
+	})
+
+	err := AcquireDirLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, dirLockName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(content)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("expected the PID in the lock file, got %q", content)
+	}
+
+	// acquiring it again in the same process is fine
+	err = AcquireDirLock()
+	if err != nil {
+		t.Errorf("expected the lock to be held already, got %v", err)
+	}
+
+	err = ReleaseDirLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, dirLockName)); !os.IsNotExist(err) {
+		t.Errorf("expected the lock file to be removed, got %v", err)
+	}
+}
+
+func TestDirLockHeldByOtherProcess(t *testing.T) {
+	dir := setupLogDir(t)
+	holdDirLock(t, dir)
+	logged := captureStdLog(t)
+
+	err := AcquireDirLock()
+	if err != nil {
+		t.Fatalf("expected a warning only, got %v", err)
+	}
+	if !strings.Contains(logged.String(), "already used by process "+strconv.Itoa(os.Getppid())) {
+		t.Errorf("expected a warning, got %q", logged.String())
+	}
+
+	// the lock of the other process is kept
+	err = ReleaseDirLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, dirLockName)); err != nil {
+		t.Errorf("expected the lock file of the other process to be kept, got %v", err)
+	}
+
+	useStrictStartup(t, RequireConfig{})
+	err = AcquireDirLock()
+	if err == nil || !strings.Contains(err.Error(), "already used by process") {
+		t.Errorf("expected an error under strict startup, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrorLogSeparate sets whether ERROR, EMERGENCY and FATAL entries are additionally written to the dated file
+// LogDir/errors-YYYY-MM-DD.log, so they can be followed without the entries of the other levels.
+// Assigning it is only safe before the first entry is logged, use SetErrorLogSeparate afterwards.
+var ErrorLogSeparate = false
+
+// ErrorLogExclusive sets whether the entries written to the error file are left out of the main log.
+// It only has an effect if ErrorLogSeparate is enabled.
+// Assigning it is only safe before the first entry is logged, use SetErrorLogExclusive afterwards.
+var ErrorLogExclusive = false
+
+// SetErrorLogSeparate sets whether ERROR and more severe entries are additionally written to the error file,
+// see ErrorLogSeparate.
+func SetErrorLogSeparate(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	ErrorLogSeparate = enabled
+}
+
+// SetErrorLogExclusive sets whether the entries written to the error file are left out of the main log,
+// see ErrorLogExclusive.
+func SetErrorLogExclusive(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	ErrorLogExclusive = enabled
+}
+
+// isErrorLogLevel reports whether entries of the given level are written to the error file with the settings s.
+func isErrorLogLevel(s settings, level string) bool {
+	return s.errorLogSeparate && LevelWeights[level] >= LevelWeights[LevelError]
+}
+
+// errorLogFilename returns the name of the error file for the given time.
+func errorLogFilename(t time.Time) string {
+	// format time to YYYY-MM-DD
+	return LogDir + "/errors-" + t.Format("2006-01-02") + ".log"
+}
+
+// writeErrorLog writes the entry to the error file of the given time. writeMu must be held.
+func writeErrorLog(t time.Time, entry string) error {
+	err := ensureLogDir()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(errorLogFilename(t), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open error log: %w", err)
+	}
+
+	err = writeEntry(f, entry)
+	if errors.Is(err, errWriteTimeout) {
+		// the file is closed in the background
+		return fmt.Errorf("could not write error log: %w", err)
+	}
+
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write error log: %w", err)
+	}
+
+	return nil
+}
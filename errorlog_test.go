@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// readErrorLog returns the lines of today's error file, which must exist.
+func readErrorLog(t *testing.T) []string {
+	t.Helper()
+
+	b, err := os.ReadFile(errorLogFilename(now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return nonEmptyLines(string(b))
+}
+
+func TestErrorLogSeparate(t *testing.T) {
+	dir := setupLogDir(t)
+	SetErrorLogSeparate(true)
+	t.Cleanup(func() {
+		SetErrorLogSeparate(false)
+	})
+
+	Info("info entry")
+	Error("error entry")
+
+	errorLines := readErrorLog(t)
+	if len(errorLines) != 1 || !strings.HasSuffix(errorLines[0], "ERROR error entry") {
+		t.Errorf("expected only the error in the error file, got %q", errorLines)
+	}
+	if lines := readMainLog(t, dir); len(lines) != 2 {
+		t.Errorf("expected both entries in the main log, got %q", lines)
+	}
+}
+
+func TestErrorLogExclusive(t *testing.T) {
+	dir := setupLogDir(t)
+	SetErrorLogSeparate(true)
+	SetErrorLogExclusive(true)
+	t.Cleanup(func() {
+		SetErrorLogSeparate(false)
+		SetErrorLogExclusive(false)
+	})
+
+	Info("info entry")
+	Log(LevelEmergency, "emergency entry")
+
+	errorLines := readErrorLog(t)
+	if len(errorLines) != 1 || !strings.HasSuffix(errorLines[0], "EMERGENCY emergency entry") {
+		t.Errorf("expected the emergency in the error file, got %q", errorLines)
+	}
+	lines := readMainLog(t, dir)
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], "INFO info entry") {
+		t.Errorf("expected only the info entry in the main log, got %q", lines)
+	}
+}
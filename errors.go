@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"log"
+	"sync"
+)
+
+var onErrorMu sync.RWMutex
+
+// OnError is called for internal failures of the logger, e.g. when a log file can't be written or an output
+// fails. This lets the application decide how to react, e.g. by counting the failures or alerting, instead of
+// the logger ending the application. The default logs the error with log.Println.
+// Assigning it directly is only safe before the first entry is logged, use SetErrorHandler afterwards.
+var OnError = defaultErrorHandler
+
+// defaultErrorHandler logs the error with log.Println.
+func defaultErrorHandler(err error) {
+	log.Println("LOGGER: " + err.Error())
+}
+
+// SetErrorHandler sets OnError, the function that is called for internal failures of the logger.
+// Passing nil restores the default, which logs the error with log.Println.
+func SetErrorHandler(handler func(error)) {
+	if handler == nil {
+		handler = defaultErrorHandler
+	}
+
+	onErrorMu.Lock()
+	OnError = handler
+	onErrorMu.Unlock()
+}
+
+// reportError passes an internal failure to OnError.
+// Entries logged by OnError itself are written to os.Stderr, see runHook.
+func reportError(err error) {
+	onErrorMu.RLock()
+	handler := OnError
+	onErrorMu.RUnlock()
+
+	// failures while a hook runs, e.g. of entries logged by the error handler itself, don't fire it again
+	if handler == nil || insideHook() {
+		handler = defaultErrorHandler
+	}
+
+	runHook(func() { handler(err) })
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useErrorHandler collects the errors passed to the error handler for the duration of the test.
+func useErrorHandler(t *testing.T) *[]error {
+	t.Helper()
+
+	var errs []error
+	SetErrorHandler(func(err error) {
+		errs = append(errs, err)
+	})
+	t.Cleanup(func() {
+		SetErrorHandler(nil)
+	})
+
+	return &errs
+}
+
+// useUnwritableLogDir points LogDir to a path below a regular file for the duration of the test, so every
+// write of the main log fails.
+func useUnwritableLogDir(t *testing.T) {
+	t.Helper()
+
+	dir := setupLogDir(t)
+	file := filepath.Join(dir, "file")
+	err := os.WriteFile(file, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setLogDir(filepath.Join(file, "logs"))
+}
+
+func TestErrorHandler(t *testing.T) {
+	useUnwritableLogDir(t)
+	errs := useErrorHandler(t)
+
+	Info("can't be written")
+
+	if len(*errs) != 1 {
+		t.Fatalf("expected the handler to be called once, got %v", *errs)
+	}
+}
+
+func TestErrorHandlerPanic(t *testing.T) {
+	useUnwritableLogDir(t)
+	SetErrorHandler(func(err error) {
+		panic("handler failed")
+	})
+	t.Cleanup(func() {
+		SetErrorHandler(nil)
+	})
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("expected the panic of the handler to be recovered, got %v", r)
+		}
+	}()
+	Info("can't be written")
+}
+
+func TestLogEReturnsWriteError(t *testing.T) {
+	useUnwritableLogDir(t)
+
+	err := LogE(LevelInfo, "can't be written")
+	if err == nil {
+		t.Fatal("expected an error for an unwritable log directory")
+	}
+
+	// the logger keeps working once the directory is writable again
+	setupLogDir(t)
+	err = LogE(LevelInfo, "written")
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestErrorHandlerClosesLogFile(t *testing.T) {
+	dir := setupLogDir(t)
+
+	// a directory in place of the component file makes every write of the component fail
+	err := os.MkdirAll(filepath.Join(dir, "billing-"+now().Format("2006-01-02")+".log"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetComponentFile("billing", true)
+	t.Cleanup(func() {
+		SetComponentFile("billing", false)
+	})
+
+	var errs []error
+	SetErrorHandler(func(err error) {
+		errs = append(errs, err)
+		CloseLogFile()
+	})
+	t.Cleanup(func() {
+		SetErrorHandler(nil)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		Named("billing").Info("invoice sent")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the error handler to be called after the log files are released")
+	}
+
+	if len(errs) != 1 {
+		t.Errorf("expected the handler to be called once, got %v", errs)
+	}
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestFatalExitCode runs itself in a subprocess, which logs a FATAL entry and must exit with the configured code.
+func TestFatalExitCode(t *testing.T) {
+	if os.Getenv("LOGGER_TEST_FATAL_SUBPROCESS") == "1" {
+		FatalPanics = false
+		SetFatalExitCode(3)
+		Fatal("fatal entry of the subprocess")
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalExitCode$")
+	cmd.Env = append(os.Environ(), "LOGGER_TEST_FATAL_SUBPROCESS=1", "LOGGER_LOG_DIR="+t.TempDir())
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected the subprocess to exit with an error, got %v", err)
+	}
+	if exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit code 3, got %d", exitErr.ExitCode())
+	}
+}
+
+func TestFatalAsError(t *testing.T) {
+	buf := captureOutput(t)
+
+	SetFatalAsError(true)
+	t.Cleanup(func() {
+		SetFatalAsError(false)
+	})
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("expected no panic, got %v", r)
+		}
+	}()
+	Fatal("downgraded")
+
+	if !strings.HasSuffix(buf.String(), "ERROR downgraded\n") {
+		t.Errorf("expected an ERROR entry, got %q", buf.String())
+	}
+}
+
+func TestFatalWithoutTerminating(t *testing.T) {
+	buf := captureOutput(t)
+
+	FatalTerminates = false
+	t.Cleanup(func() {
+		FatalTerminates = true
+	})
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("expected no panic, got %v", r)
+		}
+	}()
+	Fatal("not terminating")
+
+	if !strings.HasSuffix(buf.String(), "FATAL not terminating\n") {
+		t.Errorf("expected the FATAL entry, got %q", buf.String())
+	}
+}
+
+func TestFatalPanics(t *testing.T) {
+	buf := captureOutput(t)
+
+	defer func() {
+		r := recover()
+		if r != "panicking" {
+			t.Errorf("expected a panic with the content, got %v", r)
+		}
+		if strings.Count(buf.String(), "FATAL panicking") != 1 {
+			t.Errorf("expected the FATAL entry once, got %q", buf.String())
+		}
+	}()
+	Fatal("panicking")
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// FiberV3Ctx is the part of the fiber.Ctx interface of Fiber v3 used by LogRequestFromFiberV3.
+// It's declared here so the logger doesn't depend on Fiber v3; every fiber.Ctx of v3 implements it.
+type FiberV3Ctx interface {
+	Method(override ...string) string
+	Path(override ...string) string
+	IP() string
+	IPs() []string
+	Get(key string, defaultValue ...string) string
+	RequestCtx() *fasthttp.RequestCtx
+}
+
+// LogRequestFromFiberV3 logs the request of the given Fiber v3 context like LogRequestFromFiber does for Fiber v2.
+func LogRequestFromFiberV3(c FiberV3Ctx) {
+	// Create a new request
+	req := New()
+
+	// Set the method
+	req.Method = c.Method()
+
+	// Set the path
+	req.Path = c.Path()
+
+	// Set the IP
+	// The first entry of c.IPs() comes from the X-Forwarded-For header, which may contain arbitrary client
+	// supplied data, so it's not necessarily a valid IP.
+	req.IP = c.IP()
+	if len(c.IPs()) > 0 {
+		req.IP = c.IPs()[0]
+	}
+
+	// Set the user agent
+	req.UserAgent = c.Get(fasthttp.HeaderUserAgent)
+
+	// Set the referer
+	req.Referer = c.Get(fasthttp.HeaderReferer)
+
+	// Set the connection time, address, ID, sequence, requested host and the response
+	setFastHTTPInfo(req, c.RequestCtx(), c.IP())
+
+	enrich(req)
+
+	// Log the request
+	LogRequest(req)
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// fiberV3Ctx is a minimal stand-in for the fiber.Ctx of Fiber v3, which implements FiberV3Ctx the same way.
+type fiberV3Ctx struct {
+	ctx *fasthttp.RequestCtx
+}
+
+var _ FiberV3Ctx = fiberV3Ctx{}
+
+func (c fiberV3Ctx) Method(override ...string) string {
+	return string(c.ctx.Method())
+}
+
+func (c fiberV3Ctx) Path(override ...string) string {
+	return string(c.ctx.Path())
+}
+
+func (c fiberV3Ctx) IP() string {
+	return c.ctx.RemoteIP().String()
+}
+
+func (c fiberV3Ctx) IPs() []string {
+	header := string(c.ctx.Request.Header.Peek(fasthttp.HeaderXForwardedFor))
+	if header == "" {
+		return nil
+	}
+
+	ips := strings.Split(header, ",")
+	for i := range ips {
+		ips[i] = strings.TrimSpace(ips[i])
+	}
+	return ips
+}
+
+func (c fiberV3Ctx) Get(key string, defaultValue ...string) string {
+	return string(c.ctx.Request.Header.Peek(key))
+}
+
+func (c fiberV3Ctx) RequestCtx() *fasthttp.RequestCtx {
+	return c.ctx
+}
+
+func TestLogRequestFromFiberV3(t *testing.T) {
+	buf := captureOutput(t)
+
+	var req fasthttp.Request
+	req.Header.SetMethod("POST")
+	req.SetRequestURI("http://example.com/api/items?page=2")
+	req.Header.Set(fasthttp.HeaderUserAgent, "test-agent")
+	req.Header.Set(fasthttp.HeaderXForwardedFor, "203.0.113.7, 10.0.0.1")
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 51234}, nil)
+	ctx.SetStatusCode(201)
+
+	LogRequestFromFiberV3(fiberV3Ctx{ctx: &ctx})
+
+	if !strings.Contains(buf.String(), "INFO (POST) /api/items <- test-agent @ 203.0.113.7 -> 201") {
+		t.Errorf("expected the request entry, got %q", buf.String())
+	}
+}
@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// field is a key/value pair logged with an entry.
+type field struct {
+	// key is the key of the flattened layout, prefixed with "fields." if it's a reserved key.
+	key string
+
+	// name is the key without the prefix, used in the nested layout of the JSON format.
+	name  string
+	value interface{}
+}
+
+// reservedKeys are the keys of the structured formats that fields must not override.
+var reservedKeys = map[string]bool{
+	keyTime:           true,
+	keyEpochMillis:    true,
+	keyLevel:          true,
+	keyComponent:      true,
+	keyRuntime:        true,
+	keyStep:           true,
+	keyVersion:        true,
+	keyFingerprint:    true,
+	keyGoroutine:      true,
+	keyCommit:         true,
+	keyBuildTime:      true,
+	keyMessage:        true,
+	keyRuntimeSeconds: true,
+	keyStepSeconds:    true,
+}
+
+// LogFields logs a message with the given log level and fields, e.g.
+// LogFields(LevelInfo, "user created", map[string]interface{}{"id": 42, "name": "a b"})
+// In the text format, the fields follow the message as key=value pairs sorted by key:
+// user created id=42 name="a b"
+// Values containing spaces, quotes, equal signs or control characters are quoted.
+// In the logfmt and JSON formats, the fields are additional keys of the entry. Fields named like a reserved key
+// of the structured formats (e.g. level or msg) are prefixed with "fields.", so they can't override it.
+// In the JSON format, they can be nested in an object under the key "fields" instead, see SetNestFields.
+func LogFields(level string, msg string, fields map[string]interface{}) {
+	std.dispatchFields(level, msg, fields)
+}
+
+// LogFields logs a message with the given log level and fields. See the package-level LogFields.
+func (lg *Logger) LogFields(level string, msg string, fields map[string]interface{}) {
+	lg.dispatchFields(level, msg, fields)
+}
+
+var nestFields = false
+
+// SetNestFields sets whether the fields of an entry are nested in an object under the key "fields" in the JSON
+// format, e.g. {"ts":"...","level":"INFO","msg":"user created","fields":{"id":42,"level":"admin"}}, instead of
+// being merged with the keys of the entry, which is the default.
+// The flattened layout is easier to query, as every field is a top-level key, but fields named like a reserved
+// key are renamed to "fields.<key>". The nested layout keeps every field name as given and can never collide,
+// at the cost of one more level in queries. The other formats always use the flattened layout.
+func SetNestFields(nest bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	nestFields = nest
+}
+
+// sortedFields returns the fields sorted by key, with keys that could be mistaken for reserved keys or
+// break the key=value form replaced.
+func sortedFields(fields map[string]interface{}) []field {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	sorted := make([]field, 0, len(fields))
+	for key, value := range fields {
+		name := fieldKey(key)
+		key = name
+		if reservedKeys[key] {
+			key = "fields." + key
+		}
+		sorted = append(sorted, field{key: key, name: name, value: value})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].key < sorted[j].key
+	})
+
+	return sorted
+}
+
+// fieldKey replaces the characters of a key that would make the pair ambiguous with underscores.
+func fieldKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// coerceField converts values of types without a consistent representation in all formats to strings:
+// time.Time is formatted as RFC 3339 with fractional seconds, errors as their message, []byte as standard
+// base64 and other fmt.Stringer implementations (e.g. time.Duration) as their String result.
+// All other values are returned unchanged.
+func coerceField(value interface{}) (coerced interface{}) {
+	// a Stringer or error with a nil receiver may panic, fall back to fmt
+	defer func() {
+		if recover() != nil {
+			coerced = fmt.Sprint(value)
+		}
+	}()
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return value
+	}
+}
+
+// fieldString returns the value of a field as string for the text formats.
+func fieldString(value interface{}) string {
+	return fmt.Sprint(coerceField(value))
+}
+
+// appendTextFields appends the fields to the content as key=value pairs.
+func appendTextFields(content string, fields []field) string {
+	if len(fields) == 0 {
+		return content
+	}
+
+	var b strings.Builder
+	b.WriteString(content)
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(fieldString(f.value)))
+	}
+
+	return b.String()
+}
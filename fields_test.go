@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogFieldsText(t *testing.T) {
+	buf := captureOutput(t)
+
+	fields := map[string]interface{}{"z": 1, "key": "a b", "quote": `say "hi"`, "k=v": "x", "m": true}
+	for i := 0; i < 20; i++ {
+		LogFields(LevelInfo, "event", fields)
+	}
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 20 {
+		t.Fatalf("expected 20 lines, got %d", len(lines))
+	}
+	expected := `INFO event k_v=x key="a b" m=true quote="say \"hi\"" z=1`
+	for _, line := range lines {
+		if !strings.HasSuffix(line, expected) {
+			t.Fatalf("expected the fields sorted and quoted, got %q", line)
+		}
+	}
+}
+
+func TestNestFields(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	fields := map[string]interface{}{"id": 42, "level": "admin"}
+	LogFields(LevelInfo, "user created", fields)
+	SetNestFields(true)
+	t.Cleanup(func() {
+		SetNestFields(false)
+	})
+	LogFields(LevelInfo, "user created", fields)
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	// flattened, the field named like a reserved key is renamed
+	flat := entries[0]
+	if flat["id"] != float64(42) || flat["level"] != LevelInfo || flat["fields.level"] != "admin" {
+		t.Errorf("unexpected flattened layout: %v", flat)
+	}
+
+	// nested, every field keeps its name
+	nested, ok := entries[1]["fields"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a nested fields object, got %v", entries[1])
+	}
+	if nested["id"] != float64(42) || nested["level"] != "admin" || entries[1]["level"] != LevelInfo {
+		t.Errorf("unexpected nested layout: %v", entries[1])
+	}
+	if _, ok := entries[1]["id"]; ok {
+		t.Error("expected no top-level fields in the nested layout")
+	}
+}
+
+// nilStringer panics in String if the receiver is nil.
+type nilStringer struct {
+	name string
+}
+
+func (s *nilStringer) String() string {
+	return s.name
+}
+
+func TestFieldCoercion(t *testing.T) {
+	buf := captureOutput(t)
+
+	fields := map[string]interface{}{
+		"time":     time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC),
+		"err":      errors.New("not found"),
+		"bytes":    []byte("hi!"),
+		"duration": 1500 * time.Millisecond,
+		"nil":      (*nilStringer)(nil),
+	}
+	LogFields(LevelInfo, "text", fields)
+	useOutputFormat(t, FormatJSON)
+	LogFields(LevelInfo, "json", fields)
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+
+	expected := `INFO text bytes=aGkh duration=1.5s err="not found" nil=<nil> time=2024-01-02T03:04:05.6Z`
+	if !strings.HasSuffix(lines[0], expected) {
+		t.Errorf("expected %q, got %q", expected, lines[0])
+	}
+
+	entry := parseJSONLines(t, lines[1])[0]
+	for key, value := range map[string]string{
+		"time":     "2024-01-02T03:04:05.6Z",
+		"err":      "not found",
+		"bytes":    "aGkh",
+		"duration": "1.5s",
+		"nil":      "<nil>",
+	} {
+		if entry[key] != value {
+			t.Errorf("expected %s to be %q, got %#v", key, value, entry[key])
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strconv"
+)
+
+var includeFingerprint = false
+
+var fingerprintUUIDPattern = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+var fingerprintHexPattern = regexp.MustCompile(`(?i)\b(?:0x[0-9a-f]+|[0-9a-f]*[0-9][0-9a-f]*[a-f][0-9a-f]*|[0-9a-f]*[a-f][0-9a-f]*[0-9][0-9a-f]*)\b`)
+var fingerprintNumberPattern = regexp.MustCompile(`\d+`)
+
+// SetIncludeFingerprint sets whether the fingerprint of the content is included in every log entry.
+// See Fingerprint.
+func SetIncludeFingerprint(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	includeFingerprint = include
+}
+
+// Fingerprint returns a stable hash of the message template of the given content.
+// Variable parts like UUIDs, hexadecimal values and numbers are replaced by placeholders before hashing,
+// so messages that only differ in e.g. an embedded ID get the same fingerprint.
+// This allows aggregation systems to group occurrences of the same message.
+func Fingerprint(content string) string {
+	template := fingerprintUUIDPattern.ReplaceAllString(content, "<uuid>")
+	template = fingerprintHexPattern.ReplaceAllString(template, "<hex>")
+	template = fingerprintNumberPattern.ReplaceAllString(template, "<num>")
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(template))
+
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"user 42 not found", "user 1337 not found", true},
+		{"order 3f2b9c1e-8a4d-4b7e-9f1a-2c3d4e5f6a7b failed", "order 0d9e8f7a-6b5c-4d3e-2f1a-0b9c8d7e6f5a failed", true},
+		{"object 0xdeadbeef freed", "object 0xcafebabe freed", true},
+		{"user 42 not found", "user 42 was deleted", false},
+	}
+
+	for _, test := range tests {
+		same := Fingerprint(test.a) == Fingerprint(test.b)
+		if same != test.same {
+			t.Errorf("expected same fingerprint of %q and %q to be %v", test.a, test.b, test.same)
+		}
+	}
+}
+
+func TestIncludeFingerprint(t *testing.T) {
+	buf := captureOutput(t)
+
+	SetIncludeFingerprint(true)
+	t.Cleanup(func() {
+		SetIncludeFingerprint(false)
+	})
+	Info("user 42 logged in")
+
+	if !strings.Contains(buf.String(), "[fingerprint "+Fingerprint("user 7 logged in")+"]") {
+		t.Errorf("expected the fingerprint in the entry, got %q", buf.String())
+	}
+}
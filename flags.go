@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// boolFlag is a flag.Value for a boolean setting that is read and changed through its getter and setter, so
+// parsing the flags is as safe as calling the setter. It's a boolean flag, so it can be given without a value.
+type boolFlag struct {
+	get func() bool
+	set func(bool)
+}
+
+func (b boolFlag) String() string {
+	if b.get == nil {
+		return "false"
+	}
+
+	return strconv.FormatBool(b.get())
+}
+
+func (b boolFlag) Set(value string) error {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+
+	b.set(v)
+	return nil
+}
+
+func (b boolFlag) IsBoolFlag() bool {
+	return true
+}
+
+// RegisterFlags registers command line flags for the logger settings on the given flag set,
+// so command line tools can expose them. The values are applied when fs.Parse is called, through the same
+// Set functions as in code, so the flags can be parsed while other goroutines are logging.
+// The current settings, including the ones read from the environment variables, are shown as defaults.
+// The following flags are registered:
+// -log-level, -log-dir, -log-format, -log-component, -log-include-runtime, -log-include-step,
+// -log-requests-separately and -log-hide-requests-from-main-log
+func RegisterFlags(fs *flag.FlagSet) {
+	config := EffectiveConfig()
+
+	fs.Func("log-level", "minimum log level (DEBUG, INFO, NOTICE, WARNING, ERROR, EMERGENCY, FATAL) (default "+config.MinimumLogLevel+")", func(value string) error {
+		return SetMinimumLogLevel(strings.TrimSpace(value))
+	})
+	fs.Func("log-dir", "directory where the log files are stored (default "+config.LogDir+")", func(value string) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return errors.New("log directory must not be empty")
+		}
+
+		setLogDir(value)
+		return nil
+	})
+	fs.Func("log-format", "format of the main log (text, logfmt, json, protobuf) (default "+config.OutputFormat+")", SetOutputFormat)
+	fs.Func("log-component", "component included in every log entry", func(value string) error {
+		SetComponent(value)
+		return nil
+	})
+	fs.Var(boolFlag{func() bool { return currentSettings().includeRuntime }, SetIncludeRuntime},
+		"log-include-runtime", "include the runtime in every log entry")
+	fs.Var(boolFlag{func() bool { return currentSettings().includeStep }, SetIncludeStep},
+		"log-include-step", "include the time since the last log entry in every log entry")
+	fs.Var(boolFlag{func() bool { return currentSettings().logRequestsSeparately }, SetLogRequestsSeparately},
+		"log-requests-separately", "log requests in a separate file")
+	fs.Var(boolFlag{func() bool { return currentSettings().hideRequestsFromMainLog }, SetHideRequestsFromMainLog},
+		"log-hide-requests-from-main-log", "don't log requests in the main log file")
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	preserveConfig(t)
+	dir := t.TempDir()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+
+	err := fs.Parse([]string{
+		"-log-level", "warning",
+		"-log-dir", dir,
+		"-log-format", "json",
+		"-log-component", "worker",
+		"-log-include-runtime",
+		"-log-include-step=true",
+		"-log-requests-separately",
+		"-log-hide-requests-from-main-log=false",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := EffectiveConfig()
+	expected := Config{
+		MinimumLogLevel:       LevelWarning,
+		LogDir:                dir,
+		OutputFormat:          FormatJSON,
+		Component:             "worker",
+		IncludeRuntime:        true,
+		IncludeStep:           true,
+		LogRequestsSeparately: true,
+	}
+	if config.MinimumLogLevel != expected.MinimumLogLevel || config.LogDir != expected.LogDir ||
+		config.OutputFormat != expected.OutputFormat || config.Component != expected.Component ||
+		config.IncludeRuntime != expected.IncludeRuntime || config.IncludeStep != expected.IncludeStep ||
+		config.LogRequestsSeparately != expected.LogRequestsSeparately || config.HideRequestsFromMainLog {
+		t.Errorf("expected the flags to be applied, got %+v", config)
+	}
+}
+
+func TestRegisterFlagsRejectsInvalidValues(t *testing.T) {
+	preserveConfig(t)
+
+	for _, args := range [][]string{
+		{"-log-level", "verbose"},
+		{"-log-format", "xml"},
+		{"-log-dir", ""},
+		{"-log-include-runtime=maybe"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		RegisterFlags(fs)
+
+		if fs.Parse(args) == nil {
+			t.Errorf("expected %q to be rejected", args)
+		}
+	}
+}
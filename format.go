@@ -0,0 +1,359 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatText is the default format of the main log: [timestamp][runtime][step][component] LEVEL content
+const FormatText = "text"
+
+// FormatLogfmt writes each entry as logfmt, i.e. key=value pairs on a single line:
+// ts=... level=... component=... msg="..."
+const FormatLogfmt = "logfmt"
+
+// FormatJSON writes each entry as a JSON object on a single line, using the same keys as FormatLogfmt:
+// {"ts":"...","level":"...","component":"...","msg":"..."}
+// The log files keep the .log extension, so the file names don't depend on the format.
+const FormatJSON = "json"
+
+// OutputFormat is the format used for entries in the main log. Default: FormatText
+// Assigning it is only safe before the first entry is logged, use SetOutputFormat afterwards.
+var OutputFormat = FormatText
+
+// SetOutputFormat sets OutputFormat, the format of the entries in the main log.
+// An unknown format returns an error and leaves the current format unchanged.
+func SetOutputFormat(format string) error {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if !isValidFormat(format) {
+		return errors.New("unknown log format " + format)
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	OutputFormat = format
+	return nil
+}
+
+// The reserved keys of the structured formats.
+// User supplied fields must not override them.
+const (
+	keyTime        = "ts"
+	keyEpochMillis = "ts_ms"
+	keyLevel       = "level"
+	keyComponent   = "component"
+	keyRuntime     = "runtime"
+	keyStep        = "step"
+	keyVersion     = "logger_version"
+	keyFingerprint = "fingerprint"
+	keyGoroutine   = "goroutine"
+	keyCommit      = "commit"
+	keyBuildTime   = "build_time"
+	keyMessage     = "msg"
+
+	// keyFields holds the fields of the entry in the JSON format if they are nested, see SetNestFields.
+	keyFields = "fields"
+
+	// keyRuntimeSeconds and keyStepSeconds hold runtime and step as plain numbers of seconds,
+	// since the DD:HH:MM:SS.MICROSECONDS form can't be charted as a duration.
+	keyRuntimeSeconds = "runtime_seconds"
+	keyStepSeconds    = "step_seconds"
+)
+
+// logEntry holds everything that makes up a single log entry before it's formatted.
+type logEntry struct {
+	time      time.Time
+	level     string
+	component string
+	content   string
+
+	// includeEpochMillis adds the time as milliseconds since the Unix epoch.
+	includeEpochMillis bool
+
+	// runtime and step are given in seconds. They are only written if the respective include flag is set.
+	runtime        float64
+	step           float64
+	includeRuntime bool
+	includeStep    bool
+
+	// version is the logger version, empty if it should not be included.
+	version string
+
+	// fingerprint is the fingerprint of the content, empty if it should not be included.
+	fingerprint string
+
+	// goroutineID is the ID of the logging goroutine, 0 if it should not be included.
+	goroutineID uint64
+
+	// commit and buildTime identify the build of the application, empty if they should not be included.
+	commit    string
+	buildTime string
+
+	// fields are the additional key/value pairs of the entry, sorted by key.
+	fields []field
+
+	// nestFields writes the fields as an object under keyFields in the JSON format.
+	nestFields bool
+}
+
+var levelFormatsMu sync.RWMutex
+var levelFormats = map[string]string{}
+
+// SetFormatForLevel overrides OutputFormat for the entries of the given level, e.g. to write errors as logfmt
+// for alerting tools while the other entries stay text. Passing an empty format removes the override.
+// Note that the main log file then contains lines of different formats, so tools reading it must either
+// handle all of them or only look at the overridden levels. The level may be one of the LevelAliases.
+func SetFormatForLevel(level string, format string) {
+	level, ok := resolveLevel(level)
+	format = strings.ToLower(strings.TrimSpace(format))
+
+	levelFormatsMu.Lock()
+	defer levelFormatsMu.Unlock()
+
+	if format == "" {
+		delete(levelFormats, level)
+		return
+	}
+
+	if !ok {
+		log.Println("LOGGER: Invalid log level for format " + format + ": " + level)
+		return
+	}
+
+	if !isValidFormat(format) {
+		log.Println("LOGGER: Invalid log format for " + level + ": " + format)
+		return
+	}
+
+	levelFormats[level] = format
+}
+
+// isValidFormat returns whether the format is one of the supported output formats.
+func isValidFormat(format string) bool {
+	return format == FormatText || format == FormatLogfmt || format == FormatJSON || format == FormatProtobuf
+}
+
+// formatForLevel returns the format of the entries with the given level.
+func formatForLevel(level string) string {
+	levelFormatsMu.RLock()
+	defer levelFormatsMu.RUnlock()
+
+	if format, ok := levelFormats[level]; ok {
+		return format
+	}
+
+	return currentSettings().outputFormat
+}
+
+// formatEntry formats the entry according to the format of its level, which is OutputFormat
+// unless overridden by SetFormatForLevel.
+// The returned string is terminated by a newline, except for FormatProtobuf.
+func formatEntry(e logEntry) string {
+	switch formatForLevel(e.level) {
+	case FormatLogfmt:
+		return formatLogfmt(e)
+	case FormatJSON:
+		return formatJSON(e)
+	case FormatProtobuf:
+		return formatProtobuf(e)
+	default:
+		return formatText(e)
+	}
+}
+
+// formatText formats the entry as [timestamp][runtime][step][component] LEVEL content
+func formatText(e logEntry) string {
+	entry := "[" + e.time.Format(timeLayout()) + "]"
+	if e.includeEpochMillis {
+		entry += "[" + strconv.FormatInt(e.time.UnixMilli(), 10) + "]"
+	}
+	if e.includeRuntime {
+		entry += "[" + formatMicroTimeDuration(e.runtime) + "]"
+	}
+	if e.includeStep {
+		entry += "[" + formatMicroTimeDuration(e.step) + "]"
+	}
+
+	if e.component != "" {
+		entry += "[" + e.component + "]"
+	}
+
+	if e.version != "" {
+		entry += "[logger v" + e.version + "]"
+	}
+
+	if e.fingerprint != "" {
+		entry += "[fingerprint " + e.fingerprint + "]"
+	}
+
+	if e.goroutineID != 0 {
+		entry += "[goroutine " + strconv.FormatUint(e.goroutineID, 10) + "]"
+	}
+
+	if e.commit != "" || e.buildTime != "" {
+		entry += "[build " + strings.TrimSpace(e.commit+" "+e.buildTime) + "]"
+	}
+
+	return entry + " " + e.level + " " + appendTextFields(e.content, e.fields) + "\n"
+}
+
+// formatLogfmt formats the entry as logfmt key=value pairs.
+func formatLogfmt(e logEntry) string {
+	var b strings.Builder
+	writeLogfmtPair(&b, keyTime, e.time.Format("2006-01-02T15:04:05.000000Z07:00"))
+	if e.includeEpochMillis {
+		writeLogfmtPair(&b, keyEpochMillis, strconv.FormatInt(e.time.UnixMilli(), 10))
+	}
+	writeLogfmtPair(&b, keyLevel, e.level)
+	if e.component != "" {
+		writeLogfmtPair(&b, keyComponent, e.component)
+	}
+	if e.includeRuntime {
+		writeLogfmtPair(&b, keyRuntime, formatMicroTimeDuration(e.runtime))
+		writeLogfmtPair(&b, keyRuntimeSeconds, formatSeconds(e.runtime))
+	}
+	if e.includeStep {
+		writeLogfmtPair(&b, keyStep, formatMicroTimeDuration(e.step))
+		writeLogfmtPair(&b, keyStepSeconds, formatSeconds(e.step))
+	}
+	if e.version != "" {
+		writeLogfmtPair(&b, keyVersion, e.version)
+	}
+	if e.fingerprint != "" {
+		writeLogfmtPair(&b, keyFingerprint, e.fingerprint)
+	}
+	if e.goroutineID != 0 {
+		writeLogfmtPair(&b, keyGoroutine, strconv.FormatUint(e.goroutineID, 10))
+	}
+	if e.commit != "" {
+		writeLogfmtPair(&b, keyCommit, e.commit)
+	}
+	if e.buildTime != "" {
+		writeLogfmtPair(&b, keyBuildTime, e.buildTime)
+	}
+	writeLogfmtPair(&b, keyMessage, e.content)
+	for _, f := range e.fields {
+		writeLogfmtPair(&b, f.key, fieldString(f.value))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatJSON formats the entry as a JSON object on a single line.
+func formatJSON(e logEntry) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	writeJSONPair(&b, keyTime, e.time.Format("2006-01-02T15:04:05.000000Z07:00"))
+	if e.includeEpochMillis {
+		writeJSONPair(&b, keyEpochMillis, e.time.UnixMilli())
+	}
+	writeJSONPair(&b, keyLevel, e.level)
+	if e.component != "" {
+		writeJSONPair(&b, keyComponent, e.component)
+	}
+	if e.includeRuntime {
+		writeJSONPair(&b, keyRuntime, formatMicroTimeDuration(e.runtime))
+		writeJSONPair(&b, keyRuntimeSeconds, json.Number(formatSeconds(e.runtime)))
+	}
+	if e.includeStep {
+		writeJSONPair(&b, keyStep, formatMicroTimeDuration(e.step))
+		writeJSONPair(&b, keyStepSeconds, json.Number(formatSeconds(e.step)))
+	}
+	if e.version != "" {
+		writeJSONPair(&b, keyVersion, e.version)
+	}
+	if e.fingerprint != "" {
+		writeJSONPair(&b, keyFingerprint, e.fingerprint)
+	}
+	if e.goroutineID != 0 {
+		writeJSONPair(&b, keyGoroutine, e.goroutineID)
+	}
+	if e.commit != "" {
+		writeJSONPair(&b, keyCommit, e.commit)
+	}
+	if e.buildTime != "" {
+		writeJSONPair(&b, keyBuildTime, e.buildTime)
+	}
+	writeJSONPair(&b, keyMessage, e.content)
+	if e.nestFields && len(e.fields) > 0 {
+		var nested strings.Builder
+		nested.WriteByte('{')
+		for _, f := range e.fields {
+			writeJSONPair(&nested, f.name, coerceField(f.value))
+		}
+		nested.WriteByte('}')
+		writeJSONPair(&b, keyFields, json.RawMessage(nested.String()))
+	} else {
+		for _, f := range e.fields {
+			writeJSONPair(&b, f.key, coerceField(f.value))
+		}
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// writeJSONPair appends "key":value to the builder, separated by a comma from any previous pair.
+func writeJSONPair(b *strings.Builder, key string, value interface{}) {
+	if b.Len() > 1 {
+		b.WriteByte(',')
+	}
+	b.WriteString(jsonValue(key))
+	b.WriteByte(':')
+	b.WriteString(jsonValue(value))
+}
+
+// jsonValue encodes the value as JSON without escaping HTML characters.
+// Values that can't be encoded are written as string.
+func jsonValue(value interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	err := enc.Encode(value)
+	if err != nil {
+		buf.Reset()
+		_ = enc.Encode(fmt.Sprint(value))
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// formatSeconds formats a duration in seconds as a plain number with microsecond precision.
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 6, 64)
+}
+
+// writeLogfmtPair appends key=value to the builder, separated by a space from any previous pair.
+func writeLogfmtPair(b *strings.Builder, key string, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(logfmtValue(value))
+}
+
+// logfmtValue quotes the value if it's empty or contains spaces, quotes, equal signs or control characters.
+func logfmtValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f {
+			return strconv.Quote(value)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogfmtQuoting(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatLogfmt)
+
+	Info(`user said "hello world"`)
+
+	line := strings.TrimSpace(buf.String())
+	if !strings.HasSuffix(line, `level=INFO msg="user said \"hello world\""`) {
+		t.Errorf("expected the message to be quoted and escaped, got %q", line)
+	}
+	if !strings.HasPrefix(line, "ts=") {
+		t.Errorf("expected the line to start with the timestamp, got %q", line)
+	}
+}
+
+func TestLogfmtValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"", `""`},
+		{"two words", `"two words"`},
+		{`a"b`, `"a\"b"`},
+		{"a=b", `"a=b"`},
+		{"line\nbreak", `"line\nbreak"`},
+	}
+
+	for _, test := range tests {
+		if actual := logfmtValue(test.value); actual != test.expected {
+			t.Errorf("logfmtValue(%q) = %s, expected %s", test.value, actual, test.expected)
+		}
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	Info(`plain "entry"`)
+
+	SetComponent("api")
+	SetIncludeRuntime(true)
+	SetIncludeStep(true)
+	t.Cleanup(func() {
+		SetComponent("")
+		SetIncludeRuntime(false)
+		SetIncludeStep(false)
+	})
+	Warning("full entry")
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	// without the optional fields
+	plain := entries[0]
+	if len(plain) != 3 || plain["level"] != LevelInfo || plain["msg"] != `plain "entry"` {
+		t.Errorf("expected only ts, level and msg, got %v", plain)
+	}
+	ts, ok := plain["ts"].(string)
+	if !ok {
+		t.Fatalf("expected ts to be a string, got %#v", plain["ts"])
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05.000000Z07:00", ts); err != nil {
+		t.Errorf("expected a timestamp with microseconds, got %s", ts)
+	}
+
+	// with the optional fields
+	full := entries[1]
+	if full["level"] != LevelWarning || full["msg"] != "full entry" || full["component"] != "api" {
+		t.Errorf("unexpected entry: %v", full)
+	}
+	for _, key := range []string{"ts", "runtime", "step"} {
+		if _, ok := full[key].(string); !ok {
+			t.Errorf("expected %s to be a string, got %#v", key, full[key])
+		}
+	}
+}
+
+func TestJSONNumericDurations(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	SetIncludeRuntime(true)
+	SetIncludeStep(true)
+	t.Cleanup(func() {
+		SetIncludeRuntime(false)
+		SetIncludeStep(false)
+	})
+
+	Info("first")
+	Info("second")
+
+	for _, entry := range parseJSONLines(t, buf.String()) {
+		for _, key := range []string{"runtime_seconds", "step_seconds"} {
+			seconds, ok := entry[key].(float64)
+			if !ok {
+				t.Errorf("expected %s to be a number, got %#v", key, entry[key])
+				continue
+			}
+			if seconds < 0 || seconds > 60 {
+				t.Errorf("expected %s to be a plausible duration, got %f", key, seconds)
+			}
+		}
+		if _, ok := entry["runtime"].(string); !ok {
+			t.Errorf("expected runtime to keep its formatted form, got %#v", entry["runtime"])
+		}
+	}
+}
+
+func TestIncludeEpochMillis(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	SetIncludeEpochMillis(true)
+	t.Cleanup(func() {
+		SetIncludeEpochMillis(false)
+	})
+	Info("with epoch millis")
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	ts, err := time.Parse("2006-01-02T15:04:05.000000Z07:00", entries[0]["ts"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	millis, ok := entries[0]["ts_ms"].(float64)
+	if !ok {
+		t.Fatalf("expected ts_ms to be a number, got %#v", entries[0]["ts_ms"])
+	}
+	if int64(millis) != ts.UnixMilli() {
+		t.Errorf("expected ts_ms %d to match ts %s", int64(millis), ts)
+	}
+}
+
+func TestIncludeBuildInfo(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	SetBuildInfo("abc1234", "2023-01-01T00:00:00Z")
+	SetIncludeBuildInfo(true)
+	t.Cleanup(func() {
+		SetBuildInfo("", "")
+		SetIncludeBuildInfo(false)
+	})
+	Info("with build info")
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if entries[0]["commit"] != "abc1234" || entries[0]["build_time"] != "2023-01-01T00:00:00Z" {
+		t.Errorf("expected the build fields, got %v", entries[0])
+	}
+}
+
+func TestFormatForLevel(t *testing.T) {
+	dir := setupLogDir(t)
+
+	SetFormatForLevel(LevelError, FormatJSON)
+	t.Cleanup(func() {
+		SetFormatForLevel(LevelError, "")
+	})
+
+	Info("text entry")
+	Error("json entry")
+
+	lines := readMainLog(t, dir)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "[") || !strings.HasSuffix(lines[0], "INFO text entry") {
+		t.Errorf("expected the INFO line as text, got %q", lines[0])
+	}
+	entries := parseJSONLines(t, lines[1])
+	if entries[0]["level"] != LevelError || entries[0]["msg"] != "json entry" {
+		t.Errorf("expected the ERROR line as JSON, got %q", lines[1])
+	}
+}
+
+func TestFormatForLevelAlias(t *testing.T) {
+	dir := setupLogDir(t)
+
+	SetFormatForLevel("warn", FormatJSON)
+	t.Cleanup(func() {
+		SetFormatForLevel(LevelWarning, "")
+	})
+
+	Warning("json entry")
+
+	lines := readMainLog(t, dir)
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %q", lines)
+	}
+	entries := parseJSONLines(t, lines[0])
+	if entries[0]["level"] != LevelWarning || entries[0]["msg"] != "json entry" {
+		t.Errorf("expected the WARNING line as JSON, got %q", lines[0])
+	}
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+var includeGoroutineID = false
+
+// SetIncludeGoroutineID sets whether the ID of the logging goroutine is included in every log entry.
+// This is meant for debugging concurrency issues only and is unsuitable for production: the ID is parsed
+// from runtime.Stack on every call, which is slow, and Go doesn't guarantee the format of goroutine IDs.
+func SetIncludeGoroutineID(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	includeGoroutineID = include
+}
+
+// goroutineID returns the ID of the current goroutine, parsed from the header of its stack trace
+// ("goroutine 123 [running]:"). It returns 0 if the ID couldn't be parsed.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+
+	end := bytes.IndexByte(buf, ' ')
+	if end < 0 {
+		return 0
+	}
+
+	id, err := strconv.ParseUint(string(buf[:end]), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestIncludeGoroutineID(t *testing.T) {
+	buf := captureOutput(t)
+
+	SetIncludeGoroutineID(true)
+	t.Cleanup(func() {
+		SetIncludeGoroutineID(false)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Info("from goroutine")
+		}()
+	}
+	wg.Wait()
+
+	pattern := regexp.MustCompile(`\[goroutine (\d+)\]`)
+	ids := map[string]bool{}
+	for _, line := range nonEmptyLines(buf.String()) {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			t.Fatalf("expected a goroutine ID in %q", line)
+		}
+		ids[match[1]] = true
+	}
+
+	if len(ids) != 2 {
+		t.Errorf("expected 2 distinct goroutine IDs, got %v", ids)
+	}
+}
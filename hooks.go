@@ -0,0 +1,32 @@
+package logger
+
+import "sync"
+
+var onEntryMu sync.RWMutex
+
+// OnEntry is called synchronously for every entry after it was written, with the level, the content and the
+// entry as formatted in the log file. Entries below the minimum log level or dropped otherwise don't reach it.
+// This allows to count errors or trigger alerts from within the application. Entries logged by the hook itself
+// are written to os.Stderr instead, so they can't trigger it again. Default: nil
+// Assigning it directly is only safe before the first entry is logged, use SetEntryHook afterwards.
+var OnEntry func(level string, content string, formatted string)
+
+// SetEntryHook sets OnEntry, the function that is called for every entry. Passing nil removes the hook.
+func SetEntryHook(hook func(level string, content string, formatted string)) {
+	onEntryMu.Lock()
+	OnEntry = hook
+	onEntryMu.Unlock()
+}
+
+// runEntryHook passes the entry to OnEntry, if set.
+func runEntryHook(level string, content string, formatted string) {
+	onEntryMu.RLock()
+	hook := OnEntry
+	onEntryMu.RUnlock()
+
+	if hook == nil {
+		return
+	}
+
+	runHook(func() { hook(level, content, formatted) })
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEntryHook(t *testing.T) {
+	captureOutput(t)
+	SetMinimumLogLevel(LevelWarning)
+
+	var levels []string
+	SetEntryHook(func(level string, content string, formatted string) {
+		if !strings.HasSuffix(formatted, level+" "+content+"\n") {
+			t.Errorf("expected the formatted entry of %q, got %q", content, formatted)
+		}
+		levels = append(levels, level)
+	})
+	t.Cleanup(func() {
+		SetEntryHook(nil)
+	})
+
+	Debug("debug")
+	Info("info")
+	Warning("warning")
+	Log(LevelNotice, "notice")
+	Error("error")
+
+	if strings.Join(levels, ",") != LevelWarning+","+LevelError {
+		t.Errorf("expected the hook to see WARNING and ERROR only, got %v", levels)
+	}
+}
+
+func TestEntryHookThatLogs(t *testing.T) {
+	buf := captureOutput(t)
+	stderr := captureStream(t, &os.Stderr)
+
+	calls := 0
+	SetEntryHook(func(level string, content string, formatted string) {
+		calls++
+		Warning("hook saw " + content)
+	})
+	t.Cleanup(func() {
+		SetEntryHook(nil)
+	})
+
+	Info("entry")
+	fallback := stderr()
+
+	if calls != 1 {
+		t.Errorf("expected the hook to be called once, got %d calls", calls)
+	}
+	if !strings.HasSuffix(buf.String(), "INFO entry\n") || strings.Contains(buf.String(), "hook saw") {
+		t.Errorf("expected only the entry in the log, got %q", buf.String())
+	}
+	if !strings.HasSuffix(fallback, "WARNING hook saw entry\n") {
+		t.Errorf("expected the entry of the hook on stderr, got %q", fallback)
+	}
+}
+
+func TestErrorHandlerThatLogs(t *testing.T) {
+	useUnwritableLogDir(t)
+	stderr := captureStream(t, &os.Stderr)
+
+	calls := 0
+	SetErrorHandler(func(err error) {
+		calls++
+		Error("handler saw " + err.Error())
+	})
+	t.Cleanup(func() {
+		SetErrorHandler(nil)
+	})
+
+	Info("can't be written")
+	fallback := stderr()
+
+	if calls != 1 {
+		t.Errorf("expected the handler to be called once, got %d calls", calls)
+	}
+	if !strings.Contains(fallback, "ERROR handler saw") {
+		t.Errorf("expected the entry of the handler on stderr, got %q", fallback)
+	}
+}
@@ -0,0 +1,81 @@
+package logger
+
+import "time"
+
+// idleCloseTimeout, idleTimer and lastFileWrite are guarded by writeMu.
+var idleCloseTimeout time.Duration
+var idleTimer *time.Timer
+var lastFileWrite time.Time
+
+// openLoggerFiles are the loggers created by NewLogger that have their own main log file open, guarded by writeMu.
+var openLoggerFiles = map[*Logger]bool{}
+
+// SetIdleCloseTimeout sets the duration without entries after which the cached handles of the log files are
+// closed, so a process that stopped logging doesn't keep them open and block external rotation tools. The files
+// are reopened with the next entry. This applies to the main log file, the component files and the files of
+// loggers created by NewLogger. A value of 0 disables the idle close, which is the default.
+func SetIdleCloseTimeout(d time.Duration) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	idleCloseTimeout = d
+	if idleTimer != nil {
+		idleTimer.Stop()
+		idleTimer = nil
+	}
+
+	if d > 0 {
+		idleTimer = time.AfterFunc(d, closeIdleFiles)
+	}
+}
+
+// touchLogFiles records a write to a cached handle and schedules the idle close. writeMu must be held.
+func touchLogFiles() {
+	lastFileWrite = time.Now()
+	if idleCloseTimeout > 0 && idleTimer == nil {
+		idleTimer = time.AfterFunc(idleCloseTimeout, closeIdleFiles)
+	}
+}
+
+// closeIdleFiles closes the cached handles if nothing was written for the idle close timeout, or checks again
+// when the timeout would be reached otherwise.
+func closeIdleFiles() {
+	writeMu.Lock()
+
+	idleTimer = nil
+	if idleCloseTimeout <= 0 {
+		writeMu.Unlock()
+		return
+	}
+
+	idle := time.Since(lastFileWrite)
+	if idle < idleCloseTimeout {
+		idleTimer = time.AfterFunc(idleCloseTimeout-idle, closeIdleFiles)
+		writeMu.Unlock()
+		return
+	}
+
+	var errs []error
+	err := closeMainLogFile()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	err = closeComponentLogFiles()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for lg := range openLoggerFiles {
+		err = lg.closeLogFile()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	writeMu.Unlock()
+
+	// the error handler may close the files itself, so it's called once writeMu is released
+	for _, err := range errs {
+		reportError(err)
+	}
+}
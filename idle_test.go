@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+// mainLogFileOpen reports whether the handle of the main log file is cached.
+func mainLogFileOpen() bool {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	return mainLogFile.f != nil
+}
+
+func TestIdleClose(t *testing.T) {
+	dir := setupLogDir(t)
+
+	SetIdleCloseTimeout(50 * time.Millisecond)
+	t.Cleanup(func() {
+		SetIdleCloseTimeout(0)
+	})
+
+	Info("before idle")
+	if !mainLogFileOpen() {
+		t.Fatal("expected the handle to be cached after writing")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mainLogFileOpen() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the handle to be closed after the idle timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err := LogE(LevelInfo, "after idle")
+	if err != nil {
+		t.Fatalf("expected the file to be reopened, got %v", err)
+	}
+	if len(readMainLog(t, dir)) != 2 {
+		t.Error("expected both entries in the log file")
+	}
+}
+
+func TestIdleCloseKeepsActiveFileOpen(t *testing.T) {
+	setupLogDir(t)
+
+	SetIdleCloseTimeout(100 * time.Millisecond)
+	t.Cleanup(func() {
+		SetIdleCloseTimeout(0)
+	})
+
+	for i := 0; i < 15; i++ {
+		Info("active")
+		time.Sleep(10 * time.Millisecond)
+		if !mainLogFileOpen() {
+			t.Fatal("expected the handle to stay open while entries are written")
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package logger
+
+// The lifecycle events of the logger itself, see SetLifecycleEvents.
+const (
+	// EventFileRotated is emitted when entries start going to a new file, e.g. because the day changed or
+	// a request log segment is full.
+	EventFileRotated = "file_rotated"
+
+	// EventRetentionDeleted is emitted when old log files were deleted by the retention settings.
+	EventRetentionDeleted = "retention_deleted"
+
+	// EventSinkReconnected is emitted when a sink could send entries again after it failed.
+	EventSinkReconnected = "sink_reconnected"
+
+	// EventBufferOverflowed is emitted when a buffer was full and entries were dropped.
+	EventBufferOverflowed = "buffer_overflowed"
+)
+
+// keyEvent is the field holding the name of a lifecycle event.
+const keyEvent = "event"
+
+var lifecycleEvents = true
+
+// SetLifecycleEvents sets whether the logger logs its own lifecycle events, like rotated files or dropped
+// entries, as NOTICE entries. They consist of the message "logger event" and the fields of the event,
+// including the field event with the name of the event (see EventFileRotated etc.), e.g.
+// logger event event=file_rotated from=./logs/2024-01-01.log to=./logs/2024-01-02.log
+// This allows to monitor the logger in the same stream as the application. Default: true
+func SetLifecycleEvents(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	lifecycleEvents = enabled
+}
+
+// logLifecycleEvent logs the event with the given fields, unless lifecycle events are disabled.
+// It must not be called while writeMu or reconfigureMu is held.
+func logLifecycleEvent(event string, fields map[string]interface{}) {
+	if !currentSettings().lifecycleEvents {
+		return
+	}
+
+	eventFields := make(map[string]interface{}, len(fields)+1)
+	for key, value := range fields {
+		eventFields[key] = value
+	}
+	eventFields[keyEvent] = event
+
+	std.dispatchFields(LevelNotice, "logger event", eventFields)
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// simulateDayChange creates the main log file of yesterday in dir and makes it the file of the last entry,
+// so the next entry rotates it. It returns the name of that file.
+func simulateDayChange(t *testing.T, dir string) string {
+	t.Helper()
+
+	yesterday := now().AddDate(0, 0, -1)
+	filename := filepath.Join(dir, yesterday.Format("2006-01-02")+".log")
+	err := os.WriteFile(filename, []byte("[yesterday] INFO entry\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeMu.Lock()
+	lastEntryDate = yesterday.Format("2006-01-02")
+	lastEntryFilename = filename
+	writeMu.Unlock()
+
+	return filename
+}
+
+func TestFileRotatedEvent(t *testing.T) {
+	dir := setupLogDir(t)
+	rotated := simulateDayChange(t, dir)
+
+	Info("first entry of the day")
+
+	lines := readMainLog(t, dir)
+	if len(lines) != 2 {
+		t.Fatalf("expected the entry and the event, got %q", lines)
+	}
+	expected := "NOTICE logger event event=" + EventFileRotated + " from=" + rotated + " to=" + FilenameForTime(now())
+	if !strings.HasSuffix(lines[1], expected) {
+		t.Errorf("expected %q, got %q", expected, lines[1])
+	}
+
+	// the event is only logged once
+	Info("second entry of the day")
+	if lines := readMainLog(t, dir); len(lines) != 3 {
+		t.Errorf("expected no more events, got %q", lines)
+	}
+}
+
+func TestLifecycleEventsDisabled(t *testing.T) {
+	dir := setupLogDir(t)
+	simulateDayChange(t, dir)
+	SetLifecycleEvents(false)
+	t.Cleanup(func() {
+		SetLifecycleEvents(true)
+	})
+
+	Info("first entry of the day")
+
+	if lines := readMainLog(t, dir); len(lines) != 1 {
+		t.Errorf("expected no event, got %q", lines)
+	}
+}
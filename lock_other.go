@@ -0,0 +1,21 @@
+//go:build !unix
+
+package logger
+
+import "os"
+
+// lockFile is a no-op on systems without flock.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on systems without flock.
+func unlockFile(f *os.File) error {
+	return nil
+}
+
+// processAlive returns whether a process with the given PID is running.
+func processAlive(pid int) bool {
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package logger
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile acquires an exclusive advisory lock on the file, blocking until it's available.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+// unlockFile releases the advisory lock on the file.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// processAlive returns whether a process with the given PID is running.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
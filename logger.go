@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Logger logs entries under its own name, which is used as component of its entries.
+// Its minimum log level can be overridden with SetNamedLevel, e.g. to enable debug entries of a single
+// part of the application. All other settings are shared with the package-level functions.
+// Loggers created by NewLogger can additionally have their own minimum log level and log directory.
+type Logger struct {
+	// name is the component of the entries, empty for the package-level logger which uses Component.
+	name string
+
+	// level is the minimum log level set by NewLogger, empty if the named or global one applies.
+	level string
+
+	// logDir is the directory of the logger's own main log files set by NewLogger, empty if the entries go to
+	// the main log of the package.
+	logDir string
+
+	// includeRuntime overrides the global setting if set by NewLogger.
+	includeRuntime *bool
+
+	// output receives the entries instead of the main log if set by NewLogger, guarded by writeMu.
+	output io.Writer
+
+	// file is the cached handle of the logger's own main log file, guarded by writeMu.
+	file logFile
+}
+
+// std is the logger used by the package-level functions.
+var std = &Logger{}
+
+var namedLevelsMu sync.RWMutex
+var namedLevels = map[string]string{}
+
+// Named returns a logger whose entries have the given name as component.
+// Its minimum log level is the one set with SetNamedLevel for the name, or the global one otherwise.
+func Named(name string) *Logger {
+	return &Logger{name: name}
+}
+
+// NewLogger returns a logger configured by the given options, so parts of an application can log with
+// different components, minimum log levels and log directories at the same time:
+//
+//	api := logger.NewLogger(logger.WithComponent("api"), logger.WithLogDir("./logs/api"))
+//	jobs := logger.NewLogger(logger.WithComponent("jobs"), logger.WithMinimumLevel(logger.LevelDebug))
+//
+// WithComponent sets the component of the entries and WithMinimumLevel the minimum log level, which takes
+// precedence over SetNamedLevel and the global one. With WithLogDir, the entries are written to dated files in
+// that directory instead of the main log; the directory is created with the first entry. WithWriter writes them
+// to a writer instead, which must be safe for concurrent use if it's shared. WithIncludeRuntime overrides the
+// global setting. Settings without an option, as well as the additional destinations like the error log, the
+// outputs and the remote endpoint, are shared with the package-level functions.
+// Invalid options are reported with log.Println and ignored.
+func NewLogger(opts ...Option) *Logger {
+	o, err := applyOptions(opts)
+	if err != nil {
+		log.Println("LOGGER: " + err.Error())
+	}
+
+	lg := &Logger{}
+	if o.component != nil {
+		lg.name = *o.component
+	}
+	if o.minimumLevel != nil {
+		lg.level = *o.minimumLevel
+	}
+	if o.logDir != nil {
+		lg.logDir = *o.logDir
+	}
+	lg.includeRuntime = o.includeRuntime
+	lg.output = o.output
+
+	return lg
+}
+
+// SetNamedLevel overrides the minimum log level of the loggers with the given name.
+// An empty level removes the override, so the global minimum log level applies again.
+// The level may be one of the LevelAliases; an unknown level is ignored and leaves the current setting unchanged.
+func SetNamedLevel(name string, level string) {
+	level, ok := resolveLevel(level)
+
+	namedLevelsMu.Lock()
+	defer namedLevelsMu.Unlock()
+
+	if level == "" {
+		delete(namedLevels, name)
+		return
+	}
+
+	if !ok {
+		log.Println("LOGGER: Invalid log level for " + name + ": " + level)
+		return
+	}
+
+	namedLevels[name] = level
+}
+
+// component returns the component of the logger's entries.
+func (lg *Logger) component() string {
+	if lg.name == "" {
+		configMu.RLock()
+		defer configMu.RUnlock()
+
+		return Component
+	}
+
+	return lg.name
+}
+
+// minimumWeight returns the weight of the minimum log level of the logger.
+func (lg *Logger) minimumWeight() int {
+	if lg.level != "" {
+		return LevelWeights[lg.level]
+	}
+
+	if lg.name == "" {
+		return globalLevelWeight()
+	}
+
+	namedLevelsMu.RLock()
+	level, ok := namedLevels[lg.name]
+	namedLevelsMu.RUnlock()
+
+	if ok {
+		return LevelWeights[level]
+	}
+
+	return globalLevelWeight()
+}
+
+// writeLogFile writes the formatted entry to the logger's own main log file for the given time and returns the
+// name of the file. writeMu must be held.
+func (lg *Logger) writeLogFile(t time.Time, entry string) (string, error) {
+	filename := filepath.Join(lg.logDir, t.Format("2006-01-02")+".log")
+
+	if lg.file.f == nil || lg.file.name != filename {
+		lg.closeLogFile()
+
+		// check if the directory exists, if not create it
+		err := os.MkdirAll(lg.logDir, 0755)
+		if err != nil {
+			return filename, err
+		}
+
+		f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return filename, err
+		}
+		lg.file = logFile{f: f, name: filename}
+		openLoggerFiles[lg] = true
+	}
+
+	writeStart := time.Now()
+	err := writeEntry(lg.file.f, entry)
+	if errors.Is(err, errWriteTimeout) {
+		// the file is closed in the background, so it's reopened for the next entry
+		lg.file = logFile{}
+		delete(openLoggerFiles, lg)
+		return filename, err
+	}
+	if err != nil {
+		lg.closeLogFile()
+		return filename, err
+	}
+
+	recordWrite(len(entry), time.Since(writeStart))
+	touchLogFiles()
+	return filename, nil
+}
+
+// closeLogFile closes the cached handle of the logger's own main log file, if any. writeMu must be held.
+func (lg *Logger) closeLogFile() error {
+	if lg.file.f == nil {
+		return nil
+	}
+
+	err := lg.file.f.Close()
+	lg.file = logFile{}
+	delete(openLoggerFiles, lg)
+	return err
+}
+
+// Close closes the logger's own main log file, which is otherwise kept open between entries.
+// If another entry is logged afterwards, the file is reopened. It's a no-op for loggers without a log directory.
+func (lg *Logger) Close() error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	return lg.closeLogFile()
+}
+
+// Log logs a message with the given log level.
+func (lg *Logger) Log(level string, content string) {
+	lg.dispatch(level, content)
+}
+
+// LogE logs a message with the given log level synchronously and returns an error if it couldn't be written.
+// See the package-level LogE.
+func (lg *Logger) LogE(level string, content string) error {
+	return lg.write(level, content, nil)
+}
+
+// Debug logs a debug message.
+func (lg *Logger) Debug(content string) {
+	lg.dispatch(LevelDebug, content)
+}
+
+// Info logs an info message.
+func (lg *Logger) Info(content string) {
+	lg.dispatch(LevelInfo, content)
+}
+
+// Warning logs a warning message.
+func (lg *Logger) Warning(content string) {
+	lg.dispatch(LevelWarning, content)
+}
+
+// Error logs an err message.
+func (lg *Logger) Error(content string) {
+	lg.dispatch(LevelError, content)
+}
+
+// Fatal logs a fatal message and ends the application, see FatalPanics and FatalTerminates.
+func (lg *Logger) Fatal(content string) {
+	lg.l(LevelFatal, content)
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNamedLevels(t *testing.T) {
+	buf := captureOutput(t)
+	SetMinimumLogLevel(LevelWarning)
+
+	SetNamedLevel("db", LevelDebug)
+	SetNamedLevel("http", LevelError)
+	t.Cleanup(func() {
+		SetNamedLevel("db", "")
+		SetNamedLevel("http", "")
+	})
+
+	db := Named("db")
+	http := Named("http")
+	db.Debug("db debug")
+	http.Warning("http warning")
+	http.Error("http error")
+	Info("global info")
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got %q", lines)
+	}
+	if !strings.HasSuffix(lines[0], "[db] DEBUG db debug") {
+		t.Errorf("expected the debug entry of db, got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "[http] ERROR http error") {
+		t.Errorf("expected the error entry of http, got %q", lines[1])
+	}
+}
+
+func TestNamedLevelAlias(t *testing.T) {
+	buf := captureOutput(t)
+	SetMinimumLogLevel(LevelDebug)
+
+	SetNamedLevel("http", " err ")
+	t.Cleanup(func() {
+		SetNamedLevel("http", "")
+	})
+
+	http := Named("http")
+	http.Warning("http warning")
+	http.Error("http error")
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], "[http] ERROR http error") {
+		t.Errorf("expected only the error entry of http, got %q", lines)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	dir := setupLogDir(t)
+	SetMinimumLogLevel(LevelError)
+
+	apiDir := filepath.Join(t.TempDir(), "api")
+	jobsDir := filepath.Join(t.TempDir(), "jobs")
+	api := NewLogger(WithComponent("api"), WithLogDir(apiDir), WithMinimumLevel(LevelWarning))
+	jobs := NewLogger(WithComponent("jobs"), WithLogDir(jobsDir), WithMinimumLevel(LevelDebug))
+
+	api.Info("api info")
+	api.Warning("api warning")
+	jobs.Debug("jobs debug")
+	Warning("global warning")
+
+	apiLines := readMainLog(t, apiDir)
+	if len(apiLines) != 1 || !strings.HasSuffix(apiLines[0], "[api] WARNING api warning") {
+		t.Errorf("expected only the warning of api, got %q", apiLines)
+	}
+	jobsLines := readMainLog(t, jobsDir)
+	if len(jobsLines) != 1 || !strings.HasSuffix(jobsLines[0], "[jobs] DEBUG jobs debug") {
+		t.Errorf("expected the debug entry of jobs, got %q", jobsLines)
+	}
+
+	// the package-level logger keeps its own directory and level
+	if _, err := os.Stat(FilenameForTime(now())); !os.IsNotExist(err) {
+		t.Errorf("expected no entries in %s, got %v", dir, err)
+	}
+}
@@ -1,15 +1,26 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Version is the version of this logger package.
+// It can be included in every log entry by calling SetIncludeLoggerVersion(true).
+const Version = "1.0.0"
+
 const LevelDebug = "DEBUG"
 const LevelInfo = "INFO"
 const LevelNotice = "NOTICE"
@@ -28,74 +39,196 @@ var LevelWeights = map[string]int{
 	LevelFatal:     6,
 }
 
-var levelWeight int
+// LevelAliases maps alternative names of levels to the levels in LevelWeights, e.g. WARN to WARNING.
+// Aliases are resolved wherever a level name is parsed from user input, e.g. by IsValidLevel,
+// SetMinimumLogLevel, SetNamedLevel, SetRequestLogLevel and SetFormatForLevel. Custom levels added to
+// LevelWeights can get aliases by adding them here.
+var LevelAliases = map[string]string{
+	"WARN":  LevelWarning,
+	"ERR":   LevelError,
+	"EMERG": LevelEmergency,
+}
+
+// resolveLevel returns the level in LevelWeights named by the given level or one of its LevelAliases.
+// The name is case-insensitive and surrounding whitespace is ignored.
+func resolveLevel(level string) (string, bool) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	alias, ok := LevelAliases[level]
+	if ok {
+		level = alias
+	}
+
+	_, ok = LevelWeights[level]
+	return level, ok
+}
+
+var levelWeight = LevelWeights[minimumLogLevel]
 
 var LogDir = "./logs"
 var logDirExists = false
 var start = float64(0)
 var lastStep = float64(0)
 
+// writeMu guards the runtime and step bookkeeping and the writes to the log files,
+// so concurrently logged entries are never interleaved.
+var writeMu sync.Mutex
+
+// IncludeRuntime sets whether the runtime of the application is included in every log entry.
+// Assigning it is only safe before the first entry is logged, use SetIncludeRuntime afterwards.
 var IncludeRuntime = false
+
+// IncludeStep sets whether the time since the previous entry is included in every log entry.
+// Assigning it is only safe before the first entry is logged, use SetIncludeStep afterwards.
 var IncludeStep = false
 
+var includeLoggerVersion = false
+var includeEpochMillis = false
+
+var buildInfoCommit = ""
+var buildInfoTime = ""
+var includeBuildInfo = false
+var sanitizeUTF8 = false
+
+var writeTimeout time.Duration
+var droppedEntries atomic.Uint64
+
+// TimeLayout is the layout of the timestamp of entries in the text format, see time.Layout.
+// The logfmt and JSON formats always use RFC 3339 with microseconds. Default: 2006-01-02 15:04:05.000000
+// Assigning it is only safe before the first entry is logged, use SetTimeLayout afterwards.
+var TimeLayout = defaultTimeLayout
+
+// TimeZone is the location of the timestamps of the entries and of the dates in the names of the log files.
+// Default: nil, which uses the local time zone.
+// Assigning it is only safe before the first entry is logged, use SetTimeZone afterwards.
+var TimeZone *time.Location
+
+const defaultTimeLayout = "2006-01-02 15:04:05.000000"
+
+var disabled atomic.Bool
+
+// FatalPanics sets whether FATAL entries end the application with a panic, which can be recovered, after they
+// were written. Otherwise, the application exits with the code set by SetFatalExitCode. Default: true
+var FatalPanics = true
+
+// FatalTerminates sets whether FATAL entries end the application at all, see FatalPanics. When disabled, FATAL
+// entries are written like the entries of any other level, which allows tests to check them. Default: true
+var FatalTerminates = true
+
+var fatalExitCode = 1
+var fatalAsError = false
+var fileLocking = false
+
+var filenameFunc func(t time.Time, level string) string
+
+var errWriteTimeout = errors.New("write timed out, entry dropped")
+
 var LogRequestsSeparately = false
 var HideRequestsFromMainLog = false
 
+// MaxRequestLogSize is the maximum size in bytes of a request log file before a new segment is started.
+// Segments are numbered per day: requests-YYYY-MM-DD.csv, requests-YYYY-MM-DD.1.csv, requests-YYYY-MM-DD.2.csv, ...
+// A value of 0 disables size-based rotation.
+// Assigning it is only safe before the first request is logged, use SetMaxRequestLogSize afterwards.
+var MaxRequestLogSize int64 = 0
+
+// MaxRequestLogSegments is the number of request log segments kept per day when size-based rotation is enabled.
+// The oldest segments are removed once a new segment would exceed this number. A value of 0 keeps all segments.
+// Assigning it is only safe before the first request is logged, use SetMaxRequestLogSegments afterwards.
+var MaxRequestLogSegments = 0
+
 var minimumLogLevel = LevelNotice
 
+// Component is included in every entry of the main log.
+// Assigning it is only safe before the first entry is logged, use SetComponent afterwards.
 var Component = ""
 
-// init sets some default values by reading the environment variables.
+// startupErr is the problem of the configuration found by init, see StartupError.
+var startupErr error
+
+// init reads the environment variables. It doesn't touch the file system and never ends the application;
+// problems of the configuration are logged and returned by StartupError.
+func init() {
+	err := LoadConfigFromEnv()
+
+	// check the whole configuration under strict startup
+	if currentSettings().strictStartup {
+		validateErr := Validate()
+		if validateErr != nil {
+			err = validateErr
+		}
+	}
+
+	if err != nil {
+		startupErr = err
+		log.Println("LOGGER: " + err.Error())
+	}
+}
+
+// StartupError returns the problems of the configuration read from the environment variables when the package
+// was initialized, or nil. Under strict startup (LOGGER_STRICT_STARTUP), it includes everything reported by
+// Validate. As importing the package never ends the application, strict applications should check it at the
+// start of main and exit if it's not nil.
+func StartupError() error {
+	return startupErr
+}
+
+// LoadConfigFromEnv reads the LOGGER_* environment variables and applies them. It's called by init, and can be
+// called again later, e.g. on SIGHUP, to apply changed variables; unset variables keep the current settings.
+// Invalid values keep the current settings as well and are returned as a single error listing all of them.
 // The following environment variables are supported:
 // LOGGER_LOG_DIR: The directory where the log files are stored. Default: ./logs
 // LOGGER_INCLUDE_RUNTIME: If set to true, the runtime is included in the log entry. Default: false
 // LOGGER_INCLUDE_STEP: If set to true, the step is included in the log entry. Default: false
 // LOGGER_LOG_REQUESTS_SEPARATELY: If set to true, the requests are logged in a separate file. Default: false
 // LOGGER_HIDE_REQUESTS_FROM_MAIN_LOG: If set to true, the requests are not logged in the main log file. Default: false
-func init() {
+// LOGGER_FATAL_AS_ERROR: If set to true, fatal messages are logged as errors and don't end the application. Default: false
+// LOGGER_OUTPUT_FORMAT: The format of the main log, one of text, json, logfmt and protobuf. Default: text
+// LOGGER_GEOIP_DB: The path of a GeoIP database used to enrich logged requests. Default: none
+// LOGGER_REMOTE_ENDPOINT: The URL every entry of the main log is additionally sent to, see RemoteEndpoint. Default: none
+// LOGGER_STRICT_STARTUP: If set to true, an incomplete or invalid configuration is returned by StartupError and Configure. Default: false
+// LOGGER_DIR_LOCK: If set to true, the lock file logger.lock is acquired in the log directory with the first entry, see AcquireDirLock. Default: false
+// Boolean variables accept true, 1, yes and on as well as false, 0, no and off in any case; invalid values
+// are logged and keep the default.
+func LoadConfigFromEnv() error {
+	var problems []string
+
 	logDirTemp, logDirIsSet := os.LookupEnv("LOGGER_LOG_DIR")
 	if logDirIsSet {
 		log.Println("LOGGER: Using log directory from environment variable: " + logDirTemp)
 		logDirTemp = strings.TrimSpace(logDirTemp)
 		if logDirTemp != "" {
-			LogDir = logDirTemp
+			setLogDir(logDirTemp)
 		}
 	}
 
 	includeRuntimeTemp, includeRuntimeIsSet := os.LookupEnv("LOGGER_INCLUDE_RUNTIME")
 	if includeRuntimeIsSet {
 		log.Println("LOGGER: Using include runtime from environment variable: " + includeRuntimeTemp)
-		includeRuntimeTemp = strings.TrimSpace(includeRuntimeTemp)
-		if includeRuntimeTemp == "true" {
-			IncludeRuntime = true
-		}
+		SetIncludeRuntime(parseEnvBool("LOGGER_INCLUDE_RUNTIME", includeRuntimeTemp, currentSettings().includeRuntime))
 	}
 
 	includeStepTemp, includeStepIsSet := os.LookupEnv("LOGGER_INCLUDE_STEP")
 	if includeStepIsSet {
 		log.Println("LOGGER: Using include step from environment variable: " + includeStepTemp)
-		includeStepTemp = strings.TrimSpace(includeStepTemp)
-		if includeStepTemp == "true" {
-			IncludeStep = true
-		}
+		SetIncludeStep(parseEnvBool("LOGGER_INCLUDE_STEP", includeStepTemp, currentSettings().includeStep))
 	}
 
 	logRequestsSeparatelyTemp, logRequestsSeparatelyIsSet := os.LookupEnv("LOGGER_LOG_REQUESTS_SEPARATELY")
 	if logRequestsSeparatelyIsSet {
 		log.Println("LOGGER: Using log requests separately from environment variable: " + logRequestsSeparatelyTemp)
-		logRequestsSeparatelyTemp = strings.TrimSpace(logRequestsSeparatelyTemp)
-		if logRequestsSeparatelyTemp == "true" {
-			LogRequestsSeparately = true
-		}
+		SetLogRequestsSeparately(parseEnvBool("LOGGER_LOG_REQUESTS_SEPARATELY", logRequestsSeparatelyTemp, currentSettings().logRequestsSeparately))
 	}
 
 	hideRequestsFromMainLogTemp, hideRequestsFromMainLogIsSet := os.LookupEnv("LOGGER_HIDE_REQUESTS_FROM_MAIN_LOG")
 	if hideRequestsFromMainLogIsSet {
 		log.Println("LOGGER: Using hide requests from main log from environment variable: " + hideRequestsFromMainLogTemp)
-		hideRequestsFromMainLogTemp = strings.TrimSpace(hideRequestsFromMainLogTemp)
-		if hideRequestsFromMainLogTemp == "true" {
-			HideRequestsFromMainLog = true
-		}
+		SetHideRequestsFromMainLog(parseEnvBool("LOGGER_HIDE_REQUESTS_FROM_MAIN_LOG", hideRequestsFromMainLogTemp, currentSettings().hideRequestsFromMainLog))
+	}
+
+	fatalAsErrorTemp, fatalAsErrorIsSet := os.LookupEnv("LOGGER_FATAL_AS_ERROR")
+	if fatalAsErrorIsSet {
+		log.Println("LOGGER: Using fatal as error from environment variable: " + fatalAsErrorTemp)
+		SetFatalAsError(parseEnvBool("LOGGER_FATAL_AS_ERROR", fatalAsErrorTemp, currentSettings().fatalAsError))
 	}
 
 	minimumLogLevelTemp, minimumLogLevelIsSet := os.LookupEnv("LOGGER_MINIMUM_LOG_LEVEL")
@@ -104,46 +237,459 @@ func init() {
 		minimumLogLevelTemp = strings.TrimSpace(minimumLogLevelTemp)
 		if minimumLogLevelTemp != "" {
 			log.Println("LOGGER: Setting minimum log level to: " + minimumLogLevelTemp)
-			minimumLogLevelTemp = strings.ToUpper(minimumLogLevelTemp)
-			for key := range LevelWeights {
-				if key == minimumLogLevelTemp {
-					minimumLogLevel = minimumLogLevelTemp
-					break
-				}
+			err := SetMinimumLogLevel(minimumLogLevelTemp)
+			if err != nil {
+				problems = append(problems, "LOGGER_MINIMUM_LOG_LEVEL: "+err.Error())
 			}
 		}
 	}
 
-	// check if logs directory exists, if not create it
-	_, err := os.Stat(LogDir)
-	if os.IsNotExist(err) {
-		err = os.Mkdir(LogDir, 0755)
+	outputFormatTemp, outputFormatIsSet := os.LookupEnv("LOGGER_OUTPUT_FORMAT")
+	if outputFormatIsSet {
+		log.Println("LOGGER: Using output format from environment variable: " + outputFormatTemp)
+		outputFormatTemp = strings.ToLower(strings.TrimSpace(outputFormatTemp))
+		err := SetOutputFormat(outputFormatTemp)
 		if err != nil {
-			log.Fatal(err)
+			problems = append(problems, "LOGGER_OUTPUT_FORMAT: "+err.Error()+", using "+FormatText)
+			SetOutputFormat(FormatText)
 		}
-		log.Println("LOGGER: Created log directory: " + LogDir)
-		logDirExists = true
 	}
 
-	// set level weights
-	levelWeight = LevelWeights[minimumLogLevel]
+	geoIPDBTemp, geoIPDBIsSet := os.LookupEnv("LOGGER_GEOIP_DB")
+	if geoIPDBIsSet {
+		log.Println("LOGGER: Using GeoIP database from environment variable: " + geoIPDBTemp)
+		geoIPDBTemp = strings.TrimSpace(geoIPDBTemp)
+		if geoIPDBTemp != "" {
+			err := LoadGeoIPDB(geoIPDBTemp)
+			if err != nil {
+				problems = append(problems, "LOGGER_GEOIP_DB: could not load GeoIP database: "+err.Error())
+			}
+		}
+	}
+
+	remoteEndpointTemp, remoteEndpointIsSet := os.LookupEnv("LOGGER_REMOTE_ENDPOINT")
+	if remoteEndpointIsSet {
+		log.Println("LOGGER: Using remote endpoint from environment variable: " + remoteEndpointTemp)
+		SetRemoteEndpoint(remoteEndpointTemp)
+	}
+
+	strictStartupTemp, strictStartupIsSet := os.LookupEnv("LOGGER_STRICT_STARTUP")
+	if strictStartupIsSet {
+		log.Println("LOGGER: Using strict startup from environment variable: " + strictStartupTemp)
+		SetStrictStartup(parseEnvBool("LOGGER_STRICT_STARTUP", strictStartupTemp, currentSettings().strictStartup))
+	}
+
+	// the lock of the log directory is acquired with the first entry, so nothing is written here
+	dirLockTemp, dirLockIsSet := os.LookupEnv("LOGGER_DIR_LOCK")
+	if dirLockIsSet {
+		log.Println("LOGGER: Using dir lock from environment variable: " + dirLockTemp)
+		dirLockPending.Store(parseEnvBool("LOGGER_DIR_LOCK", dirLockTemp, false))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid logger environment: " + strings.Join(problems, "; "))
+	}
+
+	return nil
 }
 
-func SetMinimumLogLevel(level string) {
-	level = strings.ToUpper(level)
-	found := false
-	for key := range LevelWeights {
-		if key == level {
-			minimumLogLevel = level
-			levelWeight = LevelWeights[level]
-			found = true
-			break
-		}
+// parseEnvBool returns the boolean value of the environment variable with the given name.
+// Besides the values accepted by strconv.ParseBool, "yes", "y" and "on" as well as "no", "n" and "off" are
+// accepted, all case-insensitively. An invalid value is logged and the default is returned.
+func parseEnvBool(name string, value string, def bool) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "yes", "y", "on":
+		return true
+	case "no", "n", "off":
+		return false
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Println("LOGGER: Invalid boolean value for " + name + ": " + value + ", keeping the default")
+		return def
+	}
+
+	return b
+}
+
+// SetEnabled sets whether anything is logged at all. When disabled, all logging functions return right away
+// without checking the level, formatting the entry or touching any file, which e.g. keeps logging out of
+// benchmarks of the application. Unlike the minimum log level, this includes FATAL entries, so Fatal neither
+// panics nor exits the application while logging is disabled. Default: true
+func SetEnabled(enabled bool) {
+	disabled.Store(!enabled)
+}
+
+// SetIncludeRuntime sets whether the runtime of the application is included in every log entry.
+func SetIncludeRuntime(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	IncludeRuntime = include
+}
+
+// SetIncludeStep sets whether the time since the previous entry is included in every log entry.
+func SetIncludeStep(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	IncludeStep = include
+}
+
+// SetComponent sets the component included in every entry of the main log.
+func SetComponent(component string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	Component = component
+}
+
+// SetIncludeLoggerVersion sets whether the version of this package is included in every log entry.
+// This helps to correlate format differences between log files to the logger version that produced them.
+func SetIncludeLoggerVersion(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	includeLoggerVersion = include
+}
+
+// SetMinimumLogLevel sets the minimum level of the entries that are logged, the level is case-insensitive and
+// may be one of the LevelAliases. It returns an error if the level is unknown, in which case the minimum log
+// level is left unchanged. Use SetMinimumLogLevelOrDefault to fall back to NOTICE instead.
+func SetMinimumLogLevel(level string) error {
+	level, ok := resolveLevel(level)
+	if !ok {
+		return errors.New("unknown log level " + level)
+	}
+	weight := LevelWeights[level]
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	minimumLogLevel = level
+	levelWeight = weight
+	minimumLogLevelConfigured = true
+	return nil
+}
+
+// SetMinimumLogLevelOrDefault sets the minimum log level like SetMinimumLogLevel, but sets it to NOTICE if the
+// level is unknown.
+func SetMinimumLogLevelOrDefault(level string) {
+	err := SetMinimumLogLevel(level)
+	if err != nil {
+		configMu.Lock()
+		defer configMu.Unlock()
+
+		minimumLogLevel = LevelNotice
+		levelWeight = LevelWeights[LevelNotice]
+		minimumLogLevelConfigured = true
+	}
+}
+
+// GetMinimumLogLevel returns the minimum level of the entries that are logged.
+func GetMinimumLogLevel() string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return minimumLogLevel
+}
+
+// IsEnabled reports whether entries of the given level pass the minimum log level, the level is case-insensitive.
+// It allows to skip building expensive content that wouldn't be logged anyway. Unknown levels are never enabled.
+func IsEnabled(level string) bool {
+	level, ok := resolveLevel(level)
+	if !ok {
+		return false
+	}
+
+	return LevelWeights[level] >= std.minimumWeight()
+}
+
+// SetBuildInfo sets the commit and build time of the application, which are typically passed in
+// via -ldflags, e.g. go build -ldflags "-X main.commit=$(git rev-parse HEAD)". They are included in every
+// log entry if SetIncludeBuildInfo is enabled, which helps to find out which build produced an entry.
+func SetBuildInfo(commit string, buildTime string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	buildInfoCommit = strings.TrimSpace(commit)
+	buildInfoTime = strings.TrimSpace(buildTime)
+}
+
+// SetIncludeBuildInfo sets whether the build info set by SetBuildInfo is included in every log entry.
+func SetIncludeBuildInfo(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	includeBuildInfo = include
+}
+
+// SetIncludeEpochMillis sets whether the time of every entry is additionally included as milliseconds
+// since the Unix epoch, which some ingestion systems index on. It's derived from the same time as the
+// human-readable timestamp.
+func SetIncludeEpochMillis(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	includeEpochMillis = include
+}
+
+// SetSanitizeUTF8 sets whether invalid UTF-8 sequences in the content and component are replaced with
+// the Unicode replacement character before writing, e.g. when logging binary data. This keeps the log
+// file valid UTF-8 but costs an additional pass over every entry, so it's disabled by default.
+func SetSanitizeUTF8(sanitize bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	sanitizeUTF8 = sanitize
+}
+
+// SetWriteTimeout sets the maximum duration a single write to the log file may take.
+// If a write doesn't finish in time (e.g. because of a stuck network mount), the entry is dropped
+// and counted in DroppedEntries instead of blocking the caller. A value of 0 disables the timeout.
+func SetWriteTimeout(d time.Duration) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	writeTimeout = d
+}
+
+// DroppedEntries returns the number of log entries that were dropped because a write timed out.
+func DroppedEntries() uint64 {
+	return droppedEntries.Load()
+}
+
+// SetFilenameFunc overrides the name of the main log file, which is YYYY-MM-DD.log by default.
+// The function is called for every entry with its time and level, so it can e.g. embed the hostname
+// or route levels to different files. Relative names are resolved against LogDir, missing directories
+// are created. Passing nil restores the default naming.
+func SetFilenameFunc(fn func(t time.Time, level string) string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	filenameFunc = fn
+}
+
+// SetLogDir changes the directory of the log files while logging is active.
+// The directory is created if it doesn't exist. Entries that are being written while the directory changes are
+// completely written to the old directory, all later entries go to the new one, so no entry is lost or split.
+// Assigning LogDir directly is only safe before the first entry is logged.
+func SetLogDir(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	reconfigureMu.Lock()
+	LogDir = dir
+	logDirExists = true
+	reconfigureMu.Unlock()
+
+	return nil
+}
+
+// setLogDir changes the directory of the log files like SetLogDir, but leaves creating it to the next entry.
+func setLogDir(dir string) {
+	reconfigureMu.Lock()
+	defer reconfigureMu.Unlock()
+
+	LogDir = dir
+	logDirExists = false
+}
+
+// FilenameForTime returns the name of the main log file an entry at time t would be written to, without
+// creating or writing anything. This makes retention and archival tooling deterministic.
+// t is converted to TimeZone first, like the time of an entry, so the date is the one of the file the entry
+// lands in. If a filename function is set (see SetFilenameFunc), it's called with an empty level.
+func FilenameForTime(t time.Time) string {
+	reconfigureMu.RLock()
+	defer reconfigureMu.RUnlock()
+
+	return mainLogPath(inTimeZone(t), "")
+}
+
+// mainLogPath returns the name of the file an entry with the given time and level is written to.
+func mainLogPath(t time.Time, level string) string {
+	filenameFunc := currentSettings().filenameFunc
+	if filenameFunc == nil {
+		// format time to YYYY-MM-DD
+		return LogDir + "/" + t.Format("2006-01-02") + ".log"
+	}
+
+	filename := filenameFunc(t, level)
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(LogDir, filename)
+	}
+
+	return filename
+}
+
+// mainLogFilename returns the name of the file an entry with the given time and level is written to
+// and creates missing directories of a name returned by the filename function.
+func mainLogFilename(t time.Time, level string) (string, error) {
+	filename := mainLogPath(t, level)
+	if currentSettings().filenameFunc == nil {
+		return filename, nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return filename, fmt.Errorf("could not create log directory: %w", err)
+	}
+
+	return filename, nil
+}
+
+// SetFileLocking sets whether an exclusive advisory lock (flock) is held on the log file while writing.
+// Multiple processes appending to the same file, e.g. replicas sharing a volume, can otherwise interleave
+// partial lines for large entries. Locking is only supported on Unix systems and a no-op elsewhere.
+func SetFileLocking(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	fileLocking = enabled
+}
+
+// SetLogRequestsSeparately sets whether the requests are additionally logged in separate CSV files,
+// see LogRequestsSeparately.
+func SetLogRequestsSeparately(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	LogRequestsSeparately = enabled
+}
+
+// SetHideRequestsFromMainLog sets whether the requests are left out of the main log when they're logged
+// separately, see HideRequestsFromMainLog.
+func SetHideRequestsFromMainLog(hide bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	HideRequestsFromMainLog = hide
+}
+
+// SetMaxRequestLogSize sets the maximum size in bytes of a request log file, see MaxRequestLogSize.
+func SetMaxRequestLogSize(size int64) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	MaxRequestLogSize = size
+}
+
+// SetMaxRequestLogSegments sets the number of request log segments kept per day, see MaxRequestLogSegments.
+func SetMaxRequestLogSegments(segments int) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	MaxRequestLogSegments = segments
+}
+
+// SetFatalAsError sets whether fatal messages are downgraded to errors.
+// In this mode, Fatal logs the message at ERROR level and returns instead of ending the application,
+// which makes code calling Fatal safe to run in tests or serverless environments.
+func SetFatalAsError(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	fatalAsError = enabled
+}
+
+// SetFatalExitCode sets the exit code used when a FATAL entry exits the application. Default: 1
+// It only applies if FatalPanics is false: a panic always exits with the code 2 of the Go runtime.
+// Different codes for different failures let supervisors distinguish the cause of a crash.
+func SetFatalExitCode(code int) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	fatalExitCode = code
+}
+
+// IsValidLevel reports whether the given level is one of the known levels in LevelWeights, including custom
+// levels added there, or one of the LevelAliases. The check is case-insensitive and ignores surrounding
+// whitespace, like SetMinimumLogLevel, so it can be used to validate user input before passing it there.
+func IsValidLevel(level string) bool {
+	_, ok := resolveLevel(level)
+	return ok
+}
+
+// StartTimers starts measuring the runtime and the step, see IncludeRuntime and IncludeStep, from now on.
+// Without calling it, both are measured from the first entry logged. Call it at the beginning of a unit of
+// work, e.g. a job, so the runtime of its entries is relative to the beginning of the unit.
+// The timers are global, so units of work running concurrently share them.
+func StartTimers() {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	start = microTime()
+	lastStep = start
+}
+
+// ResetTimers resets the runtime and the step, so they are measured from the next entry logged again, just
+// like after the application started.
+func ResetTimers() {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	start = 0
+	lastStep = 0
+}
+
+// SetTimeLayout sets TimeLayout, the layout of the timestamps in the text format.
+// An empty layout restores the default.
+func SetTimeLayout(layout string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	TimeLayout = layout
+}
+
+// SetTimeZone sets TimeZone, the location of the timestamps and of the dates in the names of the log files.
+// Passing nil restores the local time zone.
+func SetTimeZone(loc *time.Location) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	TimeZone = loc
+}
+
+// now returns the current time in the location set by TimeZone.
+func now() time.Time {
+	configMu.RLock()
+	loc := TimeZone
+	configMu.RUnlock()
+
+	if loc != nil {
+		return time.Now().In(loc)
 	}
 
-	if !found {
-		SetMinimumLogLevel(LevelNotice)
+	return time.Now()
+}
+
+// inTimeZone returns t in the location set by TimeZone, or in the local time zone if none is set.
+func inTimeZone(t time.Time) time.Time {
+	configMu.RLock()
+	loc := TimeZone
+	configMu.RUnlock()
+
+	if loc == nil {
+		return t.Local()
 	}
+
+	return t.In(loc)
+}
+
+// timeLayout returns the layout of the timestamps in the text format.
+func timeLayout() string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	if TimeLayout == "" {
+		return defaultTimeLayout
+	}
+
+	return TimeLayout
 }
 
 // microTime returns the current time in microseconds.
@@ -154,25 +700,28 @@ func microTime() float64 {
 	return float64(now.Unix()) + micSeconds
 }
 
-// formatMicroTimeDuration formats a duration in microseconds to a string.
+// formatMicroTimeDuration formats a duration in seconds to a string.
 // The format is DD:HH:MM:SS.MICROSECONDS
+// The duration is rounded to whole microseconds first and split with integer arithmetic, so the digits are
+// exact even for long runtimes.
 func formatMicroTimeDuration(duration float64) string {
 	// Format: DD:HH:MM:SS.MICROSECONDS
 	formatString := "%02d:%02d:%02d:%02d.%06d"
 
-	days := int(duration / 86400)
-	duration -= float64(days * 86400)
+	micros := int64(math.Round(duration * 1000000))
+
+	microSeconds := micros % 1000000
+	seconds := micros / 1000000
 
-	hours := int(duration / 3600)
-	duration -= float64(hours * 3600)
+	days := seconds / 86400
+	seconds %= 86400
 
-	minutes := int(duration / 60)
-	duration -= float64(minutes * 60)
+	hours := seconds / 3600
+	seconds %= 3600
 
-	seconds := int(duration)
-	duration -= float64(seconds)
+	minutes := seconds / 60
+	seconds %= 60
 
-	microSeconds := int(duration * 1000000)
 	return fmt.Sprintf(formatString, days, hours, minutes, seconds, microSeconds)
 }
 
@@ -189,46 +738,78 @@ func createHttpClient() *http.Client {
 // It logs the given content to the main log file.
 // It's internal and should not be used directly because we provide wrapper functions for each log level below.
 func l(level string, content string) {
+	std.l(level, content)
+}
+
+// l logs the given content to the main log file using the component and minimum level of the logger.
+// Failures are passed to the error handler.
+func (lg *Logger) l(level string, content string) {
+	lg.lf(level, content, nil)
+}
+
+// lf logs the given content with fields to the main log file using the component and minimum level of the logger.
+// Failures are passed to the error handler.
+func (lg *Logger) lf(level string, content string, fields map[string]interface{}) {
+	err := lg.write(level, content, fields)
+	if err != nil {
+		reportError(err)
+	}
+}
+
+// write logs the given content with fields to the main log file using the component and minimum level of the
+// logger. It returns an error if the level is invalid or the main log file couldn't be written; failures of the
+// additional outputs are passed to the error handler.
+func (lg *Logger) write(level string, content string, fields map[string]interface{}) error {
+	// skip everything if logging is disabled
+	if disabled.Load() {
+		return nil
+	}
+
+	s := currentSettings()
+	if lg.includeRuntime != nil {
+		s.includeRuntime = *lg.includeRuntime
+	}
+
+	// downgrade fatal messages if requested
+	if level == LevelFatal && s.fatalAsError {
+		level = LevelError
+	}
+
 	// check if level is one of the supported levels
 	if _, ok := LevelWeights[level]; !ok {
-		log.Println("LOGGER: Invalid log level: " + level)
-		return
+		return errors.New("invalid log level: " + level)
+	}
+
+	// entries logged by a hook go to os.Stderr, so they can't trigger the hook again
+	if insideHook() {
+		writeFallback(level, lg.component(), content, fields)
+		return nil
 	}
 
 	// check if level is allowed
-	if levelWeight > LevelWeights[level] {
+	minimumWeight := lg.minimumWeight()
+	if minimumWeight > LevelWeights[level] {
 		log.Println("LOGGER: Log level not allowed: " + level)
-		log.Printf("LOGGER: Level weight of minimum log level: %d, level weight of selected level: %d\n", levelWeight, LevelWeights[level])
-		return
+		log.Printf("LOGGER: Level weight of minimum log level: %d, level weight of selected level: %d\n", minimumWeight, LevelWeights[level])
+		return nil
 	}
 
-	if !logDirExists {
-		// check if directory logs exists, if not create it
-		_, err := os.Stat(LogDir)
-		if os.IsNotExist(err) {
-			err = os.Mkdir(LogDir, 0755)
-			if err != nil {
-				log.Fatal(err)
-			}
-			logDirExists = true
-		}
+	// check if the message is dropped by sampling, fatal messages are always logged
+	if level != LevelFatal && sampledOut(level, content) {
+		return nil
 	}
 
-	// get the current date
-	t := time.Now()
-
-	// format time to YYYY-MM-DD
-	date := t.Format("2006-01-02")
+	// check if the message is a suppressed repetition, fatal messages are always logged
+	if level != LevelFatal && suppressRepeat(lg, level, lg.component(), content) {
+		return nil
+	}
 
-	// format time to HH:MM:SS
-	tFormatted := t.Format("2006-01-02 15:04:05.000000")
+	acquirePendingDirLock()
 
-	// open file YYYY-MM-DD.log
-	f, err := os.OpenFile(LogDir+"/"+date+".log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Fatal(err)
-	}
+	// get the current date
+	t := now()
 
+	writeMu.Lock()
 	if start == 0 {
 		start = microTime()
 		lastStep = start
@@ -237,159 +818,452 @@ func l(level string, content string) {
 	runtime := microTime() - start
 	step := microTime() - lastStep
 	lastStep = microTime()
+	writeMu.Unlock()
 
-	var runtimeFormatted string
-	var stepFormatted string
+	content = maskURLCredentials(content)
 
-	runtimeFormatted = formatMicroTimeDuration(runtime)
-	stepFormatted = formatMicroTimeDuration(step)
+	component := lg.component()
+	if s.sanitizeUTF8 {
+		content = strings.ToValidUTF8(content, "\uFFFD")
+		component = strings.ToValidUTF8(component, "\uFFFD")
+	}
 
-	entry := "[" + tFormatted + "]"
-	if IncludeRuntime {
-		entry += "[" + runtimeFormatted + "]"
+	e := logEntry{
+		time:               t,
+		level:              level,
+		component:          component,
+		content:            content,
+		runtime:            runtime,
+		step:               step,
+		includeRuntime:     s.includeRuntime,
+		includeStep:        s.includeStep,
+		includeEpochMillis: s.includeEpochMillis,
+		fields:             sortedFields(fields),
+		nestFields:         s.nestFields,
 	}
-	if IncludeStep {
-		entry += "[" + stepFormatted + "]"
+	if s.includeLoggerVersion {
+		e.version = Version
+	}
+	if s.includeFingerprint {
+		e.fingerprint = Fingerprint(content)
+	}
+	if s.includeGoroutineID {
+		e.goroutineID = goroutineID()
+	}
+	if s.includeBuildInfo {
+		e.commit = s.buildInfoCommit
+		e.buildTime = s.buildInfoTime
 	}
 
-	if Component != "" {
-		entry += "[" + Component + "]"
+	entry := formatEntry(e)
+
+	// check if the entry fits into the daily byte budget
+	allowed, exceeded, budget := budgetAllows(level, t, len(entry))
+	if exceeded {
+		lg.l(LevelWarning, fmt.Sprintf("Daily log byte budget of %d bytes exceeded, dropping entries below %s until the end of the day", budget, LevelWarning))
+	}
+	if !allowed {
+		return nil
 	}
 
-	entry += " " + level + " " + content + "\n"
+	// write to file and additional outputs, the configuration of which can't change in the meantime
+	reconfigureMu.RLock()
+	writeMu.Lock()
+	var err error
+	// failures of the additional outputs are reported once the locks are released, so the error handler can take them
+	var outputErrs []error
+	filename := ""
+	rotatedFrom := ""
+	toErrorLog := isErrorLogLevel(s, level)
+	if toErrorLog && s.errorLogExclusive {
+		err = writeErrorLog(t, entry)
+	} else {
+		if lg.output != nil {
+			_, err = io.WriteString(lg.output, entry)
+		} else if lg.logDir != "" {
+			filename, err = lg.writeLogFile(t, entry)
+		} else if mainOutput != nil {
+			err = writeMainOutput(entry)
+		} else {
+			filename, rotatedFrom, err = writeMainLogFile(t, level, entry)
+		}
 
-	// write to file
-	_, err = f.WriteString(entry)
-	if err != nil {
-		log.Fatal(err)
+		if toErrorLog {
+			errorLogErr := writeErrorLog(t, entry)
+			if errorLogErr != nil {
+				outputErrs = append(outputErrs, errorLogErr)
+			}
+		}
+	}
+	if err == nil {
+		writtenByLevel[level]++
+	}
+	componentErr := writeComponentLog(component, t, entry)
+	if componentErr != nil {
+		outputErrs = append(outputErrs, componentErr)
+	}
+	writeMu.Unlock()
+	outputErrs = append(outputErrs, writeOutputs(entry)...)
+	reconfigureMu.RUnlock()
+
+	for _, outputErr := range outputErrs {
+		reportError(outputErr)
+	}
+
+	if rotatedFrom != "" {
+		logLifecycleEvent(EventFileRotated, map[string]interface{}{"from": rotatedFrom, "to": filename})
+		compressRotatedLog(s, rotatedFrom)
+	}
+	autoPruneOldLogs(s, t.Format("2006-01-02"))
+
+	addMemoryEntry(entry)
+	writeAudit(s, entry)
+	ShipLog(entry)
+	writeStdStreams(s, level, entry)
+	writeSyslog(e)
+	runEntryHook(level, content, entry)
+
+	// end the application, this is the only terminal action of FATAL entries
+	if level == LevelFatal && FatalTerminates {
+		if FatalPanics {
+			panic(content)
+		}
+
+		log.Println(content)
+		os.Exit(s.fatalExitCode)
+	}
+
+	return err
+}
+
+// logFile is an open log file together with its name. The name contains the date, so the file is reopened
+// when the day changes.
+type logFile struct {
+	f    *os.File
+	name string
+}
+
+// mainLogFile is the cached handle of the main log file, guarded by writeMu.
+var mainLogFile logFile
+
+// lastEntryDate and lastEntryFilename are the date and main log file of the last entry, guarded by writeMu.
+var lastEntryDate = ""
+var lastEntryFilename = ""
+
+// writeMainLogFile writes the formatted entry to the main log file for the given time and level and returns
+// the name of the file. If the entry is the first one of a new day, the name of the previous file is returned
+// as well. writeMu must be held.
+func writeMainLogFile(t time.Time, level string, entry string) (string, string, error) {
+	filename, err := mainLogFilename(t, level)
+	if err == nil {
+		err = ensureLogDir()
+	}
+	if err == nil {
+		err = writeMainLog(filename, entry)
+	}
+
+	// check if this is the first entry of a new day, ignoring entries that were delayed past midnight
+	rotatedFrom := ""
+	date := t.Format("2006-01-02")
+	if lastEntryDate != "" && date > lastEntryDate {
+		rotatedFrom = lastEntryFilename
+	}
+	if date >= lastEntryDate {
+		lastEntryDate = date
+		lastEntryFilename = filename
 	}
 
-	// close file
-	err = f.Close()
+	return filename, rotatedFrom, err
+}
+
+// ensureLogDir creates LogDir if it doesn't exist. It's called with the first entry instead of when the
+// package is imported, so importing it never creates directories or fails. writeMu or reconfigureMu must be held.
+func ensureLogDir() error {
+	if logDirExists {
+		return nil
+	}
+
+	// check if logs directory exists, if not create it
+	_, err := os.Stat(LogDir)
+	if os.IsNotExist(err) {
+		err = os.MkdirAll(LogDir, 0755)
+		if err != nil {
+			return fmt.Errorf("could not create log directory: %w", err)
+		}
+		log.Println("LOGGER: Created log directory: " + LogDir)
+	} else if err != nil {
+		return fmt.Errorf("could not access log directory: %w", err)
+	}
+
+	logDirExists = true
+	return nil
+}
+
+// writeMainLog appends the formatted entry to the main log file with the given name.
+// The file is kept open for the next entries and only reopened when the name changes.
+func writeMainLog(filename string, entry string) error {
+	if mainLogFile.f == nil || mainLogFile.name != filename {
+		closeMainLogFile()
+
+		f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		mainLogFile = logFile{f: f, name: filename}
+	}
+
+	writeStart := time.Now()
+	err := writeEntry(mainLogFile.f, entry)
+	if errors.Is(err, errWriteTimeout) {
+		// the file is closed in the background, so it's reopened for the next entry
+		mainLogFile = logFile{}
+		return err
+	}
 	if err != nil {
-		log.Fatal(err)
+		// reopen the file for the next entry, it may have been removed or the disk replaced
+		closeMainLogFile()
+		return err
+	}
+
+	recordWrite(len(entry), time.Since(writeStart))
+	touchLogFiles()
+	return nil
+}
+
+// closeMainLogFile closes the cached handle of the main log file, if any. writeMu must be held.
+func closeMainLogFile() error {
+	if mainLogFile.f == nil {
+		return nil
+	}
+
+	err := mainLogFile.f.Close()
+	mainLogFile = logFile{}
+	return err
+}
+
+// CloseLogFile closes the main log file and the component files, which are otherwise kept open between entries.
+// Call it on shutdown to release the handles; if another entry is logged afterwards, the files are reopened.
+// It's also useful after external tools renamed the files, as entries are written to the open handles until then.
+func CloseLogFile() error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	err := closeComponentLogFiles()
+	mainErr := closeMainLogFile()
+	if mainErr != nil {
+		return mainErr
+	}
+
+	return err
+}
+
+// writeEntry writes the entry to the given file.
+// If a write timeout is set, the write is performed in a separate goroutine. When it doesn't finish in time,
+// errWriteTimeout is returned and the file is closed in the background as soon as the blocked write returns,
+// so it must not be used anymore.
+func writeEntry(f *os.File, entry string) error {
+	s := currentSettings()
+	if s.writeTimeout <= 0 {
+		return writeLocked(f, entry, s.fileLocking)
+	}
+
+	var mu sync.Mutex
+	finished := false
+	timedOut := false
+
+	done := make(chan error, 1)
+	go func() {
+		err := writeLocked(f, entry, s.fileLocking)
+
+		mu.Lock()
+		finished = true
+		if timedOut {
+			_ = f.Close()
+		}
+		mu.Unlock()
+
+		done <- err
+	}()
+
+	timer := time.NewTimer(s.writeTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		mu.Lock()
+		defer mu.Unlock()
+
+		// the write may have finished just now
+		if finished {
+			return <-done
+		}
+
+		timedOut = true
+		droppedEntries.Add(1)
+		return errWriteTimeout
 	}
+}
 
-	if level == LevelFatal {
-		panic(content)
+// writeLocked writes the entry to the given file.
+// If locking is true, the file is locked exclusively while writing.
+func writeLocked(f *os.File, entry string, locking bool) error {
+	if locking {
+		err := lockFile(f)
+		if err != nil {
+			return err
+		}
 	}
+
+	_, err := f.WriteString(entry)
+
+	if locking {
+		unlockErr := unlockFile(f)
+		if err == nil {
+			err = unlockErr
+		}
+	}
+
+	return err
 }
 
 // Log logs a message with the given log level.
 func Log(level string, content string) {
-	l(level, content)
+	dispatch(level, content)
 }
 
-// LogAsync logs a message with the given log level asynchronously by calling logger.l as goroutine.
+// LogE logs a message with the given log level synchronously and returns an error if the level is invalid
+// or the main log file couldn't be written, e.g. because the disk is full. Unlike Log, the error is returned
+// instead of being passed to the error handler.
+func LogE(level string, content string) error {
+	return std.write(level, content, nil)
+}
+
+// LogAsync logs a message with the given log level asynchronously.
+// The asynchronous entries are written in the order of the calls, see StartAsync.
 func LogAsync(level string, content string) {
-	go l(level, content)
+	goAsync(func() { l(level, content) })
 }
 
 // Debug logs a debug message.
 func Debug(content string) {
-	if levelWeight > LevelWeights[LevelDebug] {
+	if disabled.Load() {
+		return
+	}
+
+	if std.minimumWeight() > LevelWeights[LevelDebug] {
 		log.Println("Debug mode is disabled. To enable it set the minimum log level to debug.")
 		return
 	}
 
-	l(LevelDebug, content)
+	dispatch(LevelDebug, content)
 }
 
-// DebugAsync logs a debug message asynchronously by calling logger.l as goroutine.
+// DebugAsync logs a debug message asynchronously.
+// The asynchronous entries are written in the order of the calls, see StartAsync.
 func DebugAsync(content string) {
-	go Debug(content)
+	goAsync(func() { Debug(content) })
 }
 
 // Info logs an info message.
 func Info(content string) {
-	if levelWeight > LevelWeights[LevelInfo] {
+	if disabled.Load() {
+		return
+	}
+
+	if std.minimumWeight() > LevelWeights[LevelInfo] {
 		log.Println("Info mode is disabled. To enable it set the minimum log level to info.")
 		return
 	}
 
-	l(LevelInfo, content)
+	dispatch(LevelInfo, content)
 }
 
-// InfoAsync logs an info message asynchronously by calling logger.l as goroutine.
+// InfoAsync logs an info message asynchronously.
+// The asynchronous entries are written in the order of the calls, see StartAsync.
 func InfoAsync(content string) {
-	go Info(content)
+	goAsync(func() { Info(content) })
 }
 
 // Warning logs a warning message.
 func Warning(content string) {
-	if levelWeight > LevelWeights[LevelWarning] {
+	if disabled.Load() {
+		return
+	}
+
+	if std.minimumWeight() > LevelWeights[LevelWarning] {
 		log.Println("Warning mode is disabled. To enable it set the minimum log level to warning.")
 		return
 	}
 
-	l(LevelWarning, content)
+	dispatch(LevelWarning, content)
 }
 
-// WarningAsync logs a warning message asynchronously by calling logger.l as goroutine.
+// WarningAsync logs a warning message asynchronously.
+// The asynchronous entries are written in the order of the calls, see StartAsync.
 func WarningAsync(content string) {
-	go Warning(content)
+	goAsync(func() { Warning(content) })
 }
 
 // Error logs an err message.
 func Error(content string) {
-	if levelWeight > LevelWeights[LevelError] {
+	if disabled.Load() {
+		return
+	}
+
+	if std.minimumWeight() > LevelWeights[LevelError] {
 		log.Println("Error mode is disabled. To enable it set the minimum log level to error.")
 		return
 	}
 
-	l(LevelError, content)
+	dispatch(LevelError, content)
 }
 
-// ErrorAsync logs an err message asynchronously by calling logger.l as goroutine.
+// ErrorAsync logs an err message asynchronously.
+// The asynchronous entries are written in the order of the calls, see StartAsync.
 func ErrorAsync(content string) {
-	go Error(content)
+	goAsync(func() { Error(content) })
 }
 
-// Fatal logs a fatal message.
+// Fatal logs a fatal message and ends the application, see FatalPanics and FatalTerminates.
 func Fatal(content string) {
 	l(LevelFatal, content)
-	log.Fatal(content)
 }
 
-// FatalAsync logs a fatal message asynchronously by calling logger.l as goroutine.
+// FatalAsync logs a fatal message asynchronously.
+// The asynchronous entries are written in the order of the calls, see StartAsync.
 func FatalAsync(content string) {
-	go Fatal(content)
+	goAsync(func() { Fatal(content) })
 }
 
 // LogSimpleRequest logs a request.
 // This is mainly used by Panorama.
 // If HideRequestsFromMainLog is true, the request will not be logged to the main log file but only when LogRequestsSeparately is true.
 func LogSimpleRequest(method string, path string, userAgent string, ip string) {
-	if (!LogRequestsSeparately) || (LogRequestsSeparately && !HideRequestsFromMainLog) {
-		Log(LevelInfo, fmt.Sprintf("(%s) %s <- %s @ %s", method, path, userAgent, ip))
+	if disabled.Load() {
+		return
 	}
 
-	if LogRequestsSeparately {
-		// get the current date
-		t := time.Now()
-
-		// format time to YYYY-MM-DD
-		date := t.Format("2006-01-02")
-
-		// format time to HH:MM:SS
-		tFormatted := t.Format("2006-01-02 15:04:05.000000")
-
-		// open file requests.csv
-		f, err := os.OpenFile(LogDir+"/requests-simple-"+date+".csv", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			log.Fatal(err)
+	path = maskURLCredentials(path)
+
+	s := currentSettings()
+	if (!s.logRequestsSeparately) || (s.logRequestsSeparately && !s.hideRequestsFromMainLog) {
+		if formatForLevel(s.requestLogLevel) == FormatJSON {
+			// log the request as fields like LogRequest, so the main log can be queried by them
+			std.dispatchFields(s.requestLogLevel, "request", map[string]interface{}{
+				"method":     method,
+				"path":       path,
+				"ip":         ip,
+				"user_agent": userAgent,
+			})
+		} else {
+			Log(s.requestLogLevel, fmt.Sprintf("(%s) %s <- %s @ %s", method, path, userAgent, ip))
 		}
+	}
 
-		// replace all , with ; in user agent
-		userAgent = strings.ReplaceAll(userAgent, ",", ";")
-
-		entry := tFormatted + "," + method + "," + path + "," + userAgent + "," + ip + "\n"
-
-		// write to file
-		_, err = f.WriteString(entry)
-		if err != nil {
-			log.Fatal(err)
-		}
+	if s.logRequestsSeparately {
+		writeSimpleRequestCSV(method, path, userAgent, ip)
 	}
 }
 
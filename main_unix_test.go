@@ -0,0 +1,118 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWriteTimeout(t *testing.T) {
+	dir := setupLogDir(t)
+
+	// a FIFO nobody reads from blocks the write once its buffer is full
+	fifo := filepath.Join(dir, "blocking.log")
+	err := syscall.Mkfifo(fifo, 0644)
+	if err != nil {
+		t.Skip("could not create FIFO: " + err.Error())
+	}
+	reader, err := os.OpenFile(fifo, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	// fill the buffer of the FIFO, so the short entry below blocks, as a long entry would take seconds to
+	// prepare with the race detector; os.File would wait for the FIFO to become writable instead of failing
+	writer, err := syscall.Open(fifo, syscall.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(writer)
+	chunk := make([]byte, 4096)
+	for {
+		_, err := syscall.Write(writer, chunk)
+		if err != nil {
+			break
+		}
+	}
+
+	SetFilenameFunc(func(t time.Time, level string) string {
+		return fifo
+	})
+	SetWriteTimeout(100 * time.Millisecond)
+	t.Cleanup(func() {
+		SetFilenameFunc(nil)
+		SetWriteTimeout(0)
+	})
+
+	dropped := DroppedEntries()
+	begin := time.Now()
+	err = LogE(LevelInfo, "blocked")
+	elapsed := time.Since(begin)
+
+	if !errors.Is(err, errWriteTimeout) {
+		t.Errorf("expected the write to time out, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("expected the call to return after the timeout, took %s", elapsed)
+	}
+	if DroppedEntries() != dropped+1 {
+		t.Errorf("expected one dropped entry, got %d", DroppedEntries()-dropped)
+	}
+}
+
+func TestFileLockingPreventsTornLines(t *testing.T) {
+	dir := setupLogDir(t)
+
+	SetFileLocking(true)
+	t.Cleanup(func() {
+		SetFileLocking(false)
+	})
+
+	// the first entry creates the file, which the other writer appends to like a second process would
+	Info("first")
+	other, err := os.OpenFile(FilenameForTime(now()), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	const entries = 50
+	content := strings.Repeat("a", 1<<16)
+	otherLine := "[other process] INFO " + strings.Repeat("b", 1<<16) + "\n"
+
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < entries; i++ {
+			err := writeLocked(other, otherLine, true)
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for i := 0; i < entries; i++ {
+		Info(content)
+	}
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := readMainLog(t, dir)
+	if len(lines) != 2*entries+1 {
+		t.Fatalf("expected %d lines, got %d", 2*entries+1, len(lines))
+	}
+	for i, line := range lines[1:] {
+		if !strings.HasSuffix(line, "INFO "+content) && line+"\n" != otherLine {
+			t.Errorf("line %d is torn", i+2)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package logger
+
+import "sync"
+
+var memoryMu sync.Mutex
+var memoryMaxEntries = 0
+var memoryMaxBytes = 0
+var memoryEntries []string
+var memoryUsedBytes = 0
+
+// SetMemoryBuffer keeps the most recent formatted entries in memory, up to the given number of entries.
+// The entries can be read with MemoryEntries, e.g. to show them on a debug page.
+// A size of 0 removes the count limit; the buffer is disabled if neither a count nor a byte limit is set.
+func SetMemoryBuffer(size int) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	memoryMaxEntries = size
+	trimMemoryBuffer()
+}
+
+// SetMemoryBufferBytes limits the total size in bytes of the entries kept in memory.
+// The oldest entries are evicted to stay below the limit, in addition to the count limit of SetMemoryBuffer,
+// so a few huge entries can't consume a lot of memory. A value of 0 removes the byte limit.
+func SetMemoryBufferBytes(n int) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	memoryMaxBytes = n
+	trimMemoryBuffer()
+}
+
+// MemoryEntries returns a copy of the entries currently kept in memory, oldest first.
+func MemoryEntries() []string {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	entries := make([]string, len(memoryEntries))
+	copy(entries, memoryEntries)
+	return entries
+}
+
+// addMemoryEntry adds the formatted entry to the memory buffer, if enabled.
+func addMemoryEntry(entry string) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	if memoryMaxEntries <= 0 && memoryMaxBytes <= 0 {
+		return
+	}
+
+	memoryEntries = append(memoryEntries, entry)
+	memoryUsedBytes += len(entry)
+	trimMemoryBuffer()
+}
+
+// trimMemoryBuffer evicts the oldest entries until both limits are respected.
+// The caller must hold memoryMu.
+func trimMemoryBuffer() {
+	if memoryMaxEntries <= 0 && memoryMaxBytes <= 0 {
+		memoryEntries = nil
+		memoryUsedBytes = 0
+		return
+	}
+
+	evict := 0
+	for evict < len(memoryEntries) &&
+		((memoryMaxEntries > 0 && len(memoryEntries)-evict > memoryMaxEntries) ||
+			(memoryMaxBytes > 0 && memoryUsedBytes > memoryMaxBytes)) {
+		memoryUsedBytes -= len(memoryEntries[evict])
+		evict++
+	}
+
+	if evict > 0 {
+		memoryEntries = append([]string(nil), memoryEntries[evict:]...)
+	}
+}
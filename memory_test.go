@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemoryBufferBytes(t *testing.T) {
+	captureOutput(t)
+
+	SetMemoryBuffer(100)
+	SetMemoryBufferBytes(4096)
+	t.Cleanup(func() {
+		SetMemoryBuffer(0)
+		SetMemoryBufferBytes(0)
+	})
+
+	Info(strings.Repeat("a", 3000))
+	Info("small 1")
+	Info(strings.Repeat("b", 3000))
+	Info("small 2")
+	Info("small 3")
+
+	entries := MemoryEntries()
+	total := 0
+	for _, entry := range entries {
+		total += len(entry)
+	}
+	if total > 4096 {
+		t.Errorf("expected at most 4096 bytes, got %d", total)
+	}
+
+	// the first large entry is evicted when the second one is added, all small ones still fit
+	if len(entries) != 4 || !strings.Contains(entries[0], "small 1") || !strings.Contains(entries[3], "small 3") {
+		t.Errorf("expected only the oldest large entry to be evicted, got %d entries", len(entries))
+	}
+}
+
+func TestMemoryBufferEntries(t *testing.T) {
+	captureOutput(t)
+
+	SetMemoryBuffer(2)
+	t.Cleanup(func() {
+		SetMemoryBuffer(0)
+	})
+
+	Info("first")
+	Info("second")
+	Info("third")
+
+	entries := MemoryEntries()
+	if len(entries) != 2 || !strings.Contains(entries[0], "second") || !strings.Contains(entries[1], "third") {
+		t.Errorf("expected the 2 most recent entries, got %q", entries)
+	}
+}
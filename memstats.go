@@ -0,0 +1,21 @@
+package logger
+
+import (
+	"runtime"
+)
+
+// LogMemStats logs the current memory statistics of the Go runtime with the given level as the fields alloc,
+// total_alloc and sys in bytes, and num_gc, so they are separate keys in the structured formats. It's meant for
+// periodic resource logging without a profiler and only reads the statistics when called, as runtime.ReadMemStats
+// briefly stops the world.
+func LogMemStats(level string) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	std.dispatchFields(level, "memory stats", map[string]interface{}{
+		"alloc":       m.Alloc,
+		"total_alloc": m.TotalAlloc,
+		"sys":         m.Sys,
+		"num_gc":      m.NumGC,
+	})
+}
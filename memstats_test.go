@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestLogMemStats(t *testing.T) {
+	buf := captureOutput(t)
+
+	LogMemStats(LevelInfo)
+
+	for _, key := range []string{"alloc", "total_alloc", "sys", "num_gc"} {
+		match := regexp.MustCompile(`\b` + key + `=(\S+)`).FindStringSubmatch(buf.String())
+		if match == nil {
+			t.Errorf("expected the field %s in %q", key, buf.String())
+			continue
+		}
+		if _, err := strconv.ParseUint(match[1], 10, 64); err != nil {
+			t.Errorf("expected %s to be numeric, got %q", key, match[1])
+		}
+	}
+}
+
+func TestLogMemStatsJSON(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	LogMemStats(LevelInfo)
+
+	entry := parseJSONLines(t, buf.String())[0]
+	if entry["msg"] != "memory stats" {
+		t.Errorf("expected the message %q, got %#v", "memory stats", entry["msg"])
+	}
+	for _, key := range []string{"alloc", "total_alloc", "sys", "num_gc"} {
+		if _, ok := entry[key].(float64); !ok {
+			t.Errorf("expected %s to be a number, got %#v", key, entry[key])
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the write latency histogram buckets.
+// Writes slower than the last bound are counted in an additional overflow bucket.
+var latencyBounds = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+var asyncPending atomic.Int64
+var sampledEntries atomic.Uint64
+var writtenEntries atomic.Uint64
+var bytesWritten atomic.Uint64
+var latencyCounts = make([]atomic.Uint64, len(latencyBounds)+1)
+
+// writtenByLevel counts the entries written to the main log per level, guarded by writeMu.
+var writtenByLevel = map[string]uint64{}
+
+// startedAt is the time the package was initialized, used for the uptime in the shutdown summary.
+var startedAt = time.Now()
+
+// LatencyBucket is a bucket of the write latency histogram.
+type LatencyBucket struct {
+	// UpperBound is the inclusive upper bound of the bucket. It's 0 for the last bucket, which counts
+	// all writes slower than the previous bound.
+	UpperBound time.Duration `json:"upper_bound"`
+
+	// Count is the number of writes that took at most UpperBound, but longer than the previous bound.
+	Count uint64 `json:"count"`
+}
+
+// Metrics is a snapshot of the internal counters of the logger.
+// It helps to find out whether logging became a bottleneck.
+type Metrics struct {
+	// AsyncPending is the number of asynchronous entries that were handed over but are not written yet.
+	AsyncPending int64 `json:"async_pending"`
+
+	// DroppedTimeout is the number of entries dropped because the write timed out. See SetWriteTimeout.
+	DroppedTimeout uint64 `json:"dropped_timeout"`
+
+	// DroppedSampled is the number of entries dropped by sampling. See SetSamplingForKey and SetSampleRate.
+	DroppedSampled uint64 `json:"dropped_sampled"`
+
+	// DroppedSampledByLevel is the number of entries dropped by sampling per level.
+	DroppedSampledByLevel map[string]uint64 `json:"dropped_sampled_by_level"`
+
+	// DroppedAsync is the number of asynchronous entries dropped because the queue was full.
+	// See StartAsync and SetOverflowPolicy.
+	DroppedAsync uint64 `json:"dropped_async"`
+
+	// DroppedBudget is the number of entries dropped because the daily byte budget was exceeded.
+	// See SetDailyByteBudget.
+	DroppedBudget uint64 `json:"dropped_budget"`
+
+	// Written is the number of entries written to the main log.
+	Written uint64 `json:"written"`
+
+	// WrittenByLevel is the number of entries written to the main log per level.
+	WrittenByLevel map[string]uint64 `json:"written_by_level"`
+
+	// BytesWritten is the number of bytes written to the main log.
+	BytesWritten uint64 `json:"bytes_written"`
+
+	// WriteLatency is a histogram of the time it took to write an entry to the main log.
+	WriteLatency []LatencyBucket `json:"write_latency"`
+}
+
+// GetMetrics returns a snapshot of the internal counters of the logger.
+func GetMetrics() Metrics {
+	m := Metrics{
+		AsyncPending:   asyncPending.Load(),
+		DroppedTimeout: droppedEntries.Load(),
+		DroppedSampled: sampledEntries.Load(),
+		DroppedAsync:   asyncDropped.Load(),
+		DroppedBudget:  budgetDropped.Load(),
+		Written:        writtenEntries.Load(),
+		BytesWritten:   bytesWritten.Load(),
+		WrittenByLevel: map[string]uint64{},
+		WriteLatency:   make([]LatencyBucket, len(latencyCounts)),
+	}
+
+	writeMu.Lock()
+	for level, n := range writtenByLevel {
+		m.WrittenByLevel[level] = n
+	}
+	writeMu.Unlock()
+
+	m.DroppedSampledByLevel = map[string]uint64{}
+	keySamplingMu.Lock()
+	for level, n := range sampledByLevel {
+		m.DroppedSampledByLevel[level] = n
+	}
+	keySamplingMu.Unlock()
+
+	for i := range latencyCounts {
+		if i < len(latencyBounds) {
+			m.WriteLatency[i].UpperBound = latencyBounds[i]
+		}
+		m.WriteLatency[i].Count = latencyCounts[i].Load()
+	}
+
+	return m
+}
+
+// recordWrite updates the counters after an entry of the given size was written in the given duration.
+func recordWrite(size int, d time.Duration) {
+	writtenEntries.Add(1)
+	bytesWritten.Add(uint64(size))
+
+	for i, bound := range latencyBounds {
+		if d <= bound {
+			latencyCounts[i].Add(1)
+			return
+		}
+	}
+	latencyCounts[len(latencyBounds)].Add(1)
+}
+
+// goAsync runs fn in the asynchronous writer, see StartAsync, and counts it as pending until it returns.
+func goAsync(fn func()) {
+	asyncPending.Add(1)
+	enqueueAsync(fn)
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"testing"
+)
+
+// fillAsyncQueue blocks the asynchronous writer and queues entries until the queue is full. It returns the
+// function that releases the writer.
+func fillAsyncQueue(t *testing.T) func() {
+	t.Helper()
+
+	release := blockAsyncWriter(t)
+
+	asyncQueueMu.RLock()
+	queue := asyncQueue
+	asyncQueueMu.RUnlock()
+
+	for i := len(queue); i < cap(queue); i++ {
+		goAsync(func() {})
+	}
+
+	return release
+}
+
+func TestMetricsDroppedAsync(t *testing.T) {
+	captureOutput(t)
+
+	release := fillAsyncQueue(t)
+	before := GetMetrics().DroppedAsync
+	for i := 0; i < 10; i++ {
+		InfoAsync("overflowing entry")
+	}
+	dropped := GetMetrics().DroppedAsync - before
+	release()
+	waitAsync(t)
+
+	if dropped != 10 {
+		t.Errorf("expected 10 dropped entries, got %d", dropped)
+	}
+}
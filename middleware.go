@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Middleware returns an http.Handler that logs every request like LogRequestFromHTTP after passing it to next.
+// The request additionally contains the status code, the number of bytes of the response body and the time it
+// took to handle the request, which are appended to the entry in the main log,
+// e.g. "(GET) /users <- curl/8.0 @ 127.0.0.1 -> 200 (512 bytes, 1.2ms)".
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		begin := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+
+		next.ServeHTTP(rw.wrap(), r)
+
+		req := requestFromHTTP(r)
+		req.StatusCode = rw.statusCode()
+		req.ResponseBytes = rw.bytes
+		req.Duration = time.Since(begin)
+		LogRequest(req)
+	})
+}
+
+// responseWriter records the status code and the number of bytes written to the wrapped http.ResponseWriter.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status code, only the first call counts like for the wrapped writer.
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if rw.status == 0 {
+		rw.status = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records the number of bytes written. Like for the wrapped writer, writing without calling WriteHeader
+// first implies the status code 200.
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// wrap returns rw as an http.ResponseWriter that implements http.Flusher and http.Hijacker only if the wrapped
+// writer does, so handlers checking for them with a type assertion see the features that are actually available.
+func (rw *responseWriter) wrap() http.ResponseWriter {
+	_, flusher := rw.ResponseWriter.(http.Flusher)
+	_, hijacker := rw.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case flusher && hijacker:
+		return flushHijackResponseWriter{rw}
+	case flusher:
+		return flushResponseWriter{rw}
+	case hijacker:
+		return hijackResponseWriter{rw}
+	default:
+		return rw
+	}
+}
+
+// flush sends the buffered data to the client, the wrapped writer must implement http.Flusher.
+func (rw *responseWriter) flush() {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack lets the handler take over the connection, the wrapped writer must implement http.Hijacker.
+func (rw *responseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return rw.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// flushResponseWriter is a responseWriter of a writer that implements http.Flusher.
+type flushResponseWriter struct {
+	*responseWriter
+}
+
+// Flush sends the buffered data to the client.
+func (w flushResponseWriter) Flush() {
+	w.flush()
+}
+
+// hijackResponseWriter is a responseWriter of a writer that implements http.Hijacker.
+type hijackResponseWriter struct {
+	*responseWriter
+}
+
+// Hijack lets the handler take over the connection.
+func (w hijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// flushHijackResponseWriter is a responseWriter of a writer that implements http.Flusher and http.Hijacker.
+type flushHijackResponseWriter struct {
+	*responseWriter
+}
+
+// Flush sends the buffered data to the client.
+func (w flushHijackResponseWriter) Flush() {
+	w.flush()
+}
+
+// Hijack lets the handler take over the connection.
+func (w flushHijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController can access its other features.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// statusCode returns the status code of the response. Handlers that don't write anything respond with 200.
+func (rw *responseWriter) statusCode() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+
+	return rw.status
+}
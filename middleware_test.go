@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	buf := captureOutput(t)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/empty":
+		default:
+			w.Write([]byte("hello"))
+		}
+	}))
+
+	tests := []struct {
+		path   string
+		status int
+		bytes  int
+	}{
+		{"/hello", http.StatusOK, 5},
+		{"/missing", http.StatusNotFound, 19},
+		{"/empty", http.StatusOK, 0},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		r := httptest.NewRequest("GET", test.path, nil)
+		r.Header.Set("User-Agent", "test-agent")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("expected the handler to respond with %d, got %d", test.status, w.Code)
+		}
+		expected := fmt.Sprintf("(GET) %s <- test-agent @ 192.0.2.1 -> %d (%d bytes, ", test.path, test.status, test.bytes)
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q, got %q", expected, buf.String())
+		}
+	}
+}
+
+// plainResponseWriter hides the optional interfaces of the wrapped writer.
+type plainResponseWriter struct {
+	http.ResponseWriter
+}
+
+// hijackableResponseWriter adds http.Hijacker to the wrapped writer and records the call in hijacked.
+type hijackableResponseWriter struct {
+	http.ResponseWriter
+	hijacked *bool
+}
+
+func (w hijackableResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	*w.hijacked = true
+	return nil, nil, errors.New("not supported by the test")
+}
+
+// flushableHijackableResponseWriter adds http.Flusher of the wrapped writer to hijackableResponseWriter.
+type flushableHijackableResponseWriter struct {
+	hijackableResponseWriter
+}
+
+func (w flushableHijackableResponseWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func TestMiddlewareOptionalInterfaces(t *testing.T) {
+	captureOutput(t)
+
+	tests := []struct {
+		name     string
+		writer   func(w http.ResponseWriter, hijacked *bool) http.ResponseWriter
+		flusher  bool
+		hijacker bool
+	}{
+		{"plain", func(w http.ResponseWriter, hijacked *bool) http.ResponseWriter {
+			return plainResponseWriter{w}
+		}, false, false},
+		{"flusher", func(w http.ResponseWriter, hijacked *bool) http.ResponseWriter {
+			return w
+		}, true, false},
+		{"hijacker", func(w http.ResponseWriter, hijacked *bool) http.ResponseWriter {
+			return hijackableResponseWriter{plainResponseWriter{w}, hijacked}
+		}, false, true},
+		{"both", func(w http.ResponseWriter, hijacked *bool) http.ResponseWriter {
+			return flushableHijackableResponseWriter{hijackableResponseWriter{w, hijacked}}
+		}, true, true},
+	}
+	for _, test := range tests {
+		var flusher, hijacker bool
+		handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var f http.Flusher
+			var h http.Hijacker
+			f, flusher = w.(http.Flusher)
+			h, hijacker = w.(http.Hijacker)
+			if flusher {
+				f.Flush()
+			}
+			if hijacker {
+				h.Hijack()
+			}
+		}))
+
+		recorder := httptest.NewRecorder()
+		hijacked := false
+		handler.ServeHTTP(test.writer(recorder, &hijacked), httptest.NewRequest("GET", "/", nil))
+
+		if flusher != test.flusher || hijacker != test.hijacker {
+			t.Errorf("%s: expected Flusher %t and Hijacker %t, got %t and %t", test.name, test.flusher, test.hijacker, flusher, hijacker)
+		}
+		if recorder.Flushed != test.flusher {
+			t.Errorf("%s: expected the flush to reach the wrapped writer: %t", test.name, test.flusher)
+		}
+		if hijacked != test.hijacker {
+			t.Errorf("%s: expected the hijack to reach the wrapped writer: %t", test.name, test.hijacker)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// Option configures a Logger created by NewLogger or the package-level logger with Configure.
+type Option func(*options) error
+
+// options collects the settings of the applied options. Settings that weren't set by any option are nil.
+type options struct {
+	logDir       *string
+	minimumLevel *string
+	component    *string
+
+	includeRuntime *bool
+
+	// output is only valid if outputSet is true, as nil restores the log files
+	output    io.Writer
+	outputSet bool
+}
+
+// WithLogDir sets the directory of the main log files.
+func WithLogDir(dir string) Option {
+	return func(o *options) error {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			return errors.New("log directory must not be empty")
+		}
+
+		o.logDir = &dir
+		return nil
+	}
+}
+
+// WithMinimumLevel sets the minimum log level. Unknown levels are rejected.
+func WithMinimumLevel(level string) Option {
+	return func(o *options) error {
+		level, ok := resolveLevel(level)
+		if !ok {
+			return errors.New("unknown minimum log level " + level)
+		}
+
+		o.minimumLevel = &level
+		return nil
+	}
+}
+
+// WithComponent sets the component of the entries.
+func WithComponent(component string) Option {
+	return func(o *options) error {
+		o.component = &component
+		return nil
+	}
+}
+
+// WithIncludeRuntime sets whether the runtime is included in the entries.
+func WithIncludeRuntime(include bool) Option {
+	return func(o *options) error {
+		o.includeRuntime = &include
+		return nil
+	}
+}
+
+// WithWriter routes the main log to w instead of the dated log files, see SetOutput. Passing nil restores the
+// log files. It can't be combined with WithLogDir. Unlike WithOutput, the setting is permanent.
+func WithWriter(w io.Writer) Option {
+	return func(o *options) error {
+		o.output = w
+		o.outputSet = true
+		return nil
+	}
+}
+
+// Configure applies the given options to the package-level logger in one call, e.g.
+//
+//	err := logger.Configure(logger.WithLogDir("/var/log/app"), logger.WithMinimumLevel(logger.LevelInfo))
+//
+// The settings read from the environment variables are the baseline, options only override the settings they
+// name. If any option is invalid, e.g. an unknown level, nothing is applied and the returned error lists all
+// invalid options. Under strict startup, the resulting configuration is checked with Validate.
+func Configure(opts ...Option) error {
+	o, err := applyOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	if o.logDir != nil {
+		err = SetLogDir(*o.logDir)
+		if err != nil {
+			return err
+		}
+	}
+	if o.minimumLevel != nil {
+		SetMinimumLogLevel(*o.minimumLevel)
+	}
+	if o.component != nil {
+		SetComponent(*o.component)
+	}
+	if o.includeRuntime != nil {
+		SetIncludeRuntime(*o.includeRuntime)
+	}
+	if o.outputSet {
+		SetOutput(o.output)
+	}
+
+	// check the whole configuration under strict startup
+	if currentSettings().strictStartup {
+		return Validate()
+	}
+
+	return nil
+}
+
+// applyOptions applies the options in order and returns the collected settings together with the errors of the
+// invalid options, one per line.
+func applyOptions(opts []Option) (options, error) {
+	var o options
+	var messages []string
+	for _, opt := range opts {
+		err := opt(&o)
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+
+	// check the combinations
+	if o.logDir != nil && o.outputSet && o.output != nil {
+		messages = append(messages, "log directory and writer can't be combined")
+	}
+
+	if len(messages) > 0 {
+		return o, errors.New("invalid logger options: " + strings.Join(messages, "; "))
+	}
+
+	return o, nil
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	setupLogDir(t)
+	preserveConfig(t)
+
+	dir := filepath.Join(t.TempDir(), "configured")
+	err := Configure(WithLogDir(dir), WithMinimumLevel("warn"), WithComponent("api"), WithIncludeRuntime(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := EffectiveConfig()
+	if config.LogDir != dir || config.MinimumLogLevel != LevelWarning || config.Component != "api" || !config.IncludeRuntime {
+		t.Errorf("expected the options to be applied, got %+v", config)
+	}
+
+	Info("info")
+	Warning("warning")
+	lines := readMainLog(t, dir)
+	if len(lines) != 1 || !strings.Contains(lines[0], "[api] WARNING warning") {
+		t.Errorf("expected the warning in the configured directory, got %q", lines)
+	}
+}
+
+func TestConfigureInvalidOptions(t *testing.T) {
+	setupLogDir(t)
+	preserveConfig(t)
+	before := EffectiveConfig()
+
+	err := Configure(WithComponent("ignored"), WithMinimumLevel("VERBOSE"), WithLogDir(" "))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "unknown minimum log level VERBOSE") || !strings.Contains(err.Error(), "log directory must not be empty") {
+		t.Errorf("expected both invalid options in the error, got %v", err)
+	}
+	if EffectiveConfig() != before {
+		t.Error("expected nothing to be applied")
+	}
+
+	err = Configure(WithLogDir(t.TempDir()), WithWriter(&bytes.Buffer{}))
+	if err == nil || !strings.Contains(err.Error(), "can't be combined") {
+		t.Errorf("expected the combination to be rejected, got %v", err)
+	}
+}
+
+func TestConfigureWriter(t *testing.T) {
+	dir := setupLogDir(t)
+	preserveConfig(t)
+	t.Cleanup(func() {
+		SetOutput(nil)
+	})
+
+	var buf bytes.Buffer
+	err := Configure(WithWriter(&buf), WithMinimumLevel(LevelInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Debug("debug")
+	Info("to the writer")
+	if !strings.HasSuffix(buf.String(), "INFO to the writer\n") || strings.Contains(buf.String(), "debug") {
+		t.Errorf("expected only the info entry in the writer, got %q", buf.String())
+	}
+
+	// a nil writer restores the files
+	err = Configure(WithWriter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Info("to the file")
+	if lines := readMainLog(t, dir); len(lines) != 1 || !strings.HasSuffix(lines[0], "INFO to the file") {
+		t.Errorf("expected the entry in the log file, got %q", lines)
+	}
+}
@@ -0,0 +1,220 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// output is an additional writer that receives every entry of the main log.
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var outputsMu sync.RWMutex
+var outputs []*output
+
+// reconfigureMu is held for reading while an entry is written to the log file and the outputs, and for writing
+// while they are reconfigured. This way every entry is written completely to either the old or the new
+// configuration and never to an output that is being removed.
+var reconfigureMu sync.RWMutex
+
+// mainOutput replaces the main log file if set, guarded by reconfigureMu and writeMu.
+var mainOutput io.Writer
+
+var stdStreams = false
+var stdStreamsMu sync.Mutex
+
+// addOutput registers w as an additional output and returns its handle for removeOutput.
+func addOutput(w io.Writer) *output {
+	o := &output{w: w}
+
+	reconfigureMu.Lock()
+	defer reconfigureMu.Unlock()
+
+	outputsMu.Lock()
+	outputs = append(outputs, o)
+	outputsMu.Unlock()
+
+	return o
+}
+
+// removeOutput unregisters the output with the given handle.
+func removeOutput(o *output) {
+	reconfigureMu.Lock()
+	defer reconfigureMu.Unlock()
+
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+
+	for i, registered := range outputs {
+		if registered == o {
+			outputs = append(outputs[:i:i], outputs[i+1:]...)
+			return
+		}
+	}
+}
+
+// sink is a writer registered by AddSink together with the handle of its output.
+type sink struct {
+	w      io.Writer
+	handle *output
+}
+
+var sinksMu sync.Mutex
+var sinks []sink
+
+// AddSink registers w as an additional destination of the main log, e.g. os.Stdout next to the log file.
+// Every entry is written to all sinks in the order they were added, in the same format as in the log file.
+// A failing sink doesn't prevent the entry from being written to the others; the failure is passed to the
+// error handler. Writes to a sink are serialized, so w doesn't have to be safe for concurrent use.
+func AddSink(w io.Writer) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	sinks = append(sinks, sink{w: w, handle: addOutput(w)})
+}
+
+// RemoveSink unregisters a writer added by AddSink. If it was added several times, only the first registration
+// is removed. The writer is compared with ==, so it should be a pointer like *os.File or *bytes.Buffer.
+// Writers of types that can't be compared, e.g. a struct holding a slice, can't be removed; the call is logged
+// and ignored instead of panicking.
+func RemoveSink(w io.Writer) {
+	// check if the writer can be compared at all, == panics otherwise
+	if w == nil || !reflect.TypeOf(w).Comparable() {
+		log.Printf("LOGGER: Could not remove sink of type %T, its type is not comparable\n", w)
+		return
+	}
+
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	for i, s := range sinks {
+		if s.w == w {
+			removeOutput(s.handle)
+			sinks = append(sinks[:i:i], sinks[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetOutput routes the main log to w instead of the dated files in LogDir, e.g. os.Stdout in containers or a
+// bytes.Buffer in tests. No log files are created for the main log then. Passing nil restores the files.
+// Additional outputs, component files and the separate request logs are not affected.
+func SetOutput(w io.Writer) {
+	reconfigureMu.Lock()
+	defer reconfigureMu.Unlock()
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	mainOutput = w
+}
+
+// writeMainOutput writes the formatted entry to the writer set by SetOutput. writeMu must be held.
+func writeMainOutput(entry string) error {
+	writeStart := time.Now()
+	_, err := io.WriteString(mainOutput, entry)
+	if err != nil {
+		return err
+	}
+
+	recordWrite(len(entry), time.Since(writeStart))
+	return nil
+}
+
+// flushOutputs flushes the writers set by SetOutput and AddSink that buffer entries, so nothing is lost when the
+// application exits: a BufferedWriter is closed, which stops its periodic flush, and other writers with a
+// Flush() error method like bufio.Writer are flushed. The writers themselves are left open.
+func flushOutputs() error {
+	reconfigureMu.RLock()
+	writers := []io.Writer{mainOutput}
+	outputsMu.RLock()
+	for _, o := range outputs {
+		writers = append(writers, o.w)
+	}
+	outputsMu.RUnlock()
+	reconfigureMu.RUnlock()
+
+	var firstErr error
+	for _, w := range writers {
+		var err error
+		switch w := w.(type) {
+		case *BufferedWriter:
+			err = w.Close()
+		case interface{ Flush() error }:
+			err = w.Flush()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("could not flush output: %w", err)
+		}
+	}
+
+	return firstErr
+}
+
+// writeOutputs writes the formatted entry to all registered outputs and returns the errors of the failing ones.
+// A failing output doesn't prevent the entry from being written to the others.
+func writeOutputs(entry string) []error {
+	outputsMu.RLock()
+	defer outputsMu.RUnlock()
+
+	var errs []error
+	for _, o := range outputs {
+		o.mu.Lock()
+		_, err := io.WriteString(o.w, entry)
+		o.mu.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not write to output: %w", err))
+		}
+	}
+
+	return errs
+}
+
+// SetStdStreams sets whether entries are additionally written to the standard streams.
+// DEBUG, INFO and NOTICE entries go to os.Stdout, WARNING and more severe entries go to os.Stderr,
+// formatted the same way as in the log file. The log file is still written.
+func SetStdStreams(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	stdStreams = enabled
+}
+
+// writeStdStreams writes the formatted entry to os.Stdout or os.Stderr depending on its level, if enabled in s.
+func writeStdStreams(s settings, level string, entry string) {
+	if !s.stdStreams {
+		return
+	}
+
+	stream := os.Stdout
+	if LevelWeights[level] >= LevelWeights[LevelWarning] {
+		stream = os.Stderr
+	}
+
+	stdStreamsMu.Lock()
+	defer stdStreamsMu.Unlock()
+
+	_, err := stream.WriteString(entry)
+	if err != nil {
+		reportError(fmt.Errorf("could not write to standard stream: %w", err))
+	}
+}
+
+// WithOutput adds w as an additional output of the main log, runs fn and removes w again.
+// This captures the entries logged while fn runs, which is handy in tests and for targeted debugging.
+// Note that w receives all entries logged during that time, including the ones of other goroutines.
+// Only entries logged synchronously by fn itself are guaranteed to be captured; entries logged
+// asynchronously (e.g. with InfoAsync) may be written after w was already removed.
+func WithOutput(w io.Writer, fn func()) {
+	o := addOutput(w)
+	defer removeOutput(o)
+
+	fn()
+}
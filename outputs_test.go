@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWithOutput(t *testing.T) {
+	setupLogDir(t)
+
+	var buf bytes.Buffer
+	Info("before")
+	WithOutput(&buf, func() {
+		Info("inside")
+	})
+	Info("after")
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], "INFO inside") {
+		t.Errorf("expected only the entry logged inside the callback, got %q", lines)
+	}
+}
+
+// captureStream replaces the given standard stream by a temporary file until the returned function is called,
+// which restores it and returns what was written.
+func captureStream(t *testing.T, stream **os.File) func() string {
+	t.Helper()
+
+	f, err := os.CreateTemp(t.TempDir(), "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := *stream
+	*stream = f
+
+	return func() string {
+		*stream = original
+		f.Close()
+
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(b)
+	}
+}
+
+func TestStdStreams(t *testing.T) {
+	setupLogDir(t)
+
+	SetStdStreams(true)
+	t.Cleanup(func() {
+		SetStdStreams(false)
+	})
+
+	stdout := captureStream(t, &os.Stdout)
+	stderr := captureStream(t, &os.Stderr)
+	Debug("debug entry")
+	Info("info entry")
+	Warning("warning entry")
+	Error("error entry")
+	errOutput := stderr()
+	output := stdout()
+
+	for _, entry := range []string{"debug entry", "info entry"} {
+		if !strings.Contains(output, entry) || strings.Contains(errOutput, entry) {
+			t.Errorf("expected %q only on stdout", entry)
+		}
+	}
+	for _, entry := range []string{"warning entry", "error entry"} {
+		if !strings.Contains(errOutput, entry) || strings.Contains(output, entry) {
+			t.Errorf("expected %q only on stderr", entry)
+		}
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	buf, dir := captureOutputAndDir(t)
+
+	Info("to the buffer")
+
+	if !strings.HasSuffix(buf.String(), "INFO to the buffer\n") {
+		t.Errorf("expected the entry in the buffer, got %q", buf.String())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no log files, got %d entries in the log directory", len(entries))
+	}
+
+	// nil restores the files
+	SetOutput(nil)
+	Info("to the file")
+	if lines := readMainLog(t, dir); len(lines) != 1 || !strings.HasSuffix(lines[0], "INFO to the file") {
+		t.Errorf("expected the entry in the log file, got %q", lines)
+	}
+	if strings.Contains(buf.String(), "to the file") {
+		t.Error("expected no more entries in the buffer")
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("sink down")
+}
+
+// sliceWriter is a writer of a type that can't be compared with ==.
+type sliceWriter struct {
+	lines []string
+}
+
+func (w sliceWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func TestSinks(t *testing.T) {
+	dir := setupLogDir(t)
+	errs := useErrorHandler(t)
+
+	var first, second bytes.Buffer
+	AddSink(&first)
+	AddSink(failingWriter{})
+	AddSink(&second)
+	t.Cleanup(func() {
+		RemoveSink(&first)
+		RemoveSink(failingWriter{})
+		RemoveSink(&second)
+	})
+
+	Info("to every sink")
+
+	for i, buf := range []*bytes.Buffer{&first, &second} {
+		if !strings.HasSuffix(buf.String(), "INFO to every sink\n") {
+			t.Errorf("expected the entry in sink %d, got %q", i, buf.String())
+		}
+	}
+	if lines := readMainLog(t, dir); len(lines) != 1 {
+		t.Errorf("expected the entry in the log file, got %q", lines)
+	}
+	if len(*errs) != 1 {
+		t.Errorf("expected the failing sink to be reported once, got %v", *errs)
+	}
+
+	RemoveSink(&first)
+	Info("after removing")
+	if strings.Contains(first.String(), "after removing") || !strings.Contains(second.String(), "after removing") {
+		t.Error("expected only the removed sink to stop receiving entries")
+	}
+}
+
+func TestRemoveSinkNotComparable(t *testing.T) {
+	setupLogDir(t)
+	logged := captureStdLog(t)
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("expected no panic, got %v", r)
+		}
+	}()
+	RemoveSink(sliceWriter{})
+	RemoveSink(nil)
+
+	if strings.Count(logged.String(), "is not comparable") != 2 {
+		t.Errorf("expected both calls to be logged, got %q", logged.String())
+	}
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Progress logs the progress of a long-running operation without writing an entry per step.
+// An entry is logged whenever Interval has passed or the completion advanced by PercentStep percent
+// since the last entry, and a final entry once the total is reached.
+//
+//	p := logger.NewProgress("import", len(rows))
+//	for _, row := range rows {
+//		importRow(row)
+//		p.Increment()
+//	}
+type Progress struct {
+	// Interval is the time after which the progress is logged even if PercentStep wasn't reached.
+	// A value of 0 disables the time-based entries. Default: 10s
+	Interval time.Duration
+
+	// PercentStep is the completion in percent after which the progress is logged.
+	// A value of 0 disables the percentage-based entries. Default: 10
+	PercentStep float64
+
+	// Level is the level of the progress entries. Default: INFO
+	Level string
+
+	mu          sync.Mutex
+	name        string
+	total       int
+	current     int
+	started     time.Time
+	lastLog     time.Time
+	lastPercent float64
+	done        bool
+}
+
+// NewProgress returns a Progress for the operation with the given name and total number of steps.
+func NewProgress(name string, total int) *Progress {
+	now := time.Now()
+
+	return &Progress{
+		Interval:    10 * time.Second,
+		PercentStep: 10,
+		Level:       LevelInfo,
+		name:        name,
+		total:       total,
+		started:     now,
+		lastLog:     now,
+	}
+}
+
+// Increment advances the progress by one step.
+func (p *Progress) Increment() {
+	p.Add(1)
+}
+
+// Add advances the progress by n steps and logs the progress if it's due.
+func (p *Progress) Add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done {
+		return
+	}
+
+	p.current += n
+	now := time.Now()
+
+	if p.current >= p.total {
+		p.done = true
+		Log(p.Level, fmt.Sprintf("%s: %d/%d (100.0%%) done in %s", p.name, p.current, p.total, now.Sub(p.started)))
+		return
+	}
+
+	percent := float64(p.current) * 100 / float64(p.total)
+	intervalDue := p.Interval > 0 && now.Sub(p.lastLog) >= p.Interval
+	percentDue := p.PercentStep > 0 && percent-p.lastPercent >= p.PercentStep
+	if !intervalDue && !percentDue {
+		return
+	}
+
+	p.lastLog = now
+	p.lastPercent = percent
+	Log(p.Level, fmt.Sprintf("%s: %d/%d (%.1f%%)", p.name, p.current, p.total, percent))
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	buf := captureOutput(t)
+
+	p := NewProgress("import", 1000)
+	p.Interval = 0
+	p.PercentStep = 25
+	for i := 0; i < 1000; i++ {
+		p.Increment()
+	}
+	p.Increment()
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 4 {
+		t.Fatalf("expected 3 throttled entries and a final one, got %q", lines)
+	}
+	for i, percent := range []string{"(25.0%)", "(50.0%)", "(75.0%)"} {
+		if !strings.Contains(lines[i], percent) {
+			t.Errorf("expected %s in %q", percent, lines[i])
+		}
+	}
+	if !strings.Contains(lines[3], "import: 1000/1000 (100.0%) done in") {
+		t.Errorf("expected the final entry, got %q", lines[3])
+	}
+}
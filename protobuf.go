@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// FormatProtobuf writes each entry as a length-delimited protobuf message, i.e. the size of the message as a
+// varint followed by the message. It's meant for files or outputs read by dedicated pipelines, which decode
+// them with NewEntryReader, and should not be mixed with the text formats in the same file.
+// Fields of the entry (see LogFields) are appended to the message as key=value pairs.
+// The message is defined as:
+//
+//	message Entry {
+//	  int64 time_unix_nano = 1;
+//	  string level = 2;
+//	  string component = 3;
+//	  string msg = 4;
+//	  double runtime_seconds = 5;
+//	  double step_seconds = 6;
+//	  string logger_version = 7;
+//	  string fingerprint = 8;
+//	  uint64 goroutine = 9;
+//	  string commit = 10;
+//	  string build_time = 11;
+//	}
+const FormatProtobuf = "protobuf"
+
+// field numbers of the Entry message
+const (
+	protoFieldTime           = 1
+	protoFieldLevel          = 2
+	protoFieldComponent      = 3
+	protoFieldMessage        = 4
+	protoFieldRuntimeSeconds = 5
+	protoFieldStepSeconds    = 6
+	protoFieldVersion        = 7
+	protoFieldFingerprint    = 8
+	protoFieldGoroutine      = 9
+	protoFieldCommit         = 10
+	protoFieldBuildTime      = 11
+)
+
+// wire types of the protobuf encoding
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+// protoMaxMessageSize is the maximum size of a message accepted by EntryReader.
+const protoMaxMessageSize = 64 * 1024 * 1024
+
+var errInvalidProtobuf = errors.New("invalid protobuf entry")
+
+// Entry is a decoded log entry of the protobuf format. Optional fields are empty or 0 if they weren't included.
+type Entry struct {
+	Time           time.Time
+	Level          string
+	Component      string
+	Message        string
+	RuntimeSeconds float64
+	StepSeconds    float64
+	LoggerVersion  string
+	Fingerprint    string
+	Goroutine      uint64
+	Commit         string
+	BuildTime      string
+}
+
+// formatProtobuf encodes the entry as a length-delimited protobuf message.
+func formatProtobuf(e logEntry) string {
+	var msg []byte
+	msg = appendProtoVarintField(msg, protoFieldTime, uint64(e.time.UnixNano()))
+	msg = appendProtoStringField(msg, protoFieldLevel, e.level)
+	msg = appendProtoStringField(msg, protoFieldComponent, e.component)
+	msg = appendProtoStringField(msg, protoFieldMessage, appendTextFields(e.content, e.fields))
+	if e.includeRuntime {
+		msg = appendProtoDoubleField(msg, protoFieldRuntimeSeconds, e.runtime)
+	}
+	if e.includeStep {
+		msg = appendProtoDoubleField(msg, protoFieldStepSeconds, e.step)
+	}
+	msg = appendProtoStringField(msg, protoFieldVersion, e.version)
+	msg = appendProtoStringField(msg, protoFieldFingerprint, e.fingerprint)
+	if e.goroutineID != 0 {
+		msg = appendProtoVarintField(msg, protoFieldGoroutine, e.goroutineID)
+	}
+	msg = appendProtoStringField(msg, protoFieldCommit, e.commit)
+	msg = appendProtoStringField(msg, protoFieldBuildTime, e.buildTime)
+
+	b := binary.AppendUvarint(make([]byte, 0, len(msg)+binary.MaxVarintLen64), uint64(len(msg)))
+	return string(append(b, msg...))
+}
+
+// appendProtoVarintField appends a varint field with the given number.
+func appendProtoVarintField(b []byte, field int, value uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|protoWireVarint))
+	return binary.AppendUvarint(b, value)
+}
+
+// appendProtoStringField appends a string field with the given number, empty strings are omitted.
+func appendProtoStringField(b []byte, field int, value string) []byte {
+	if value == "" {
+		return b
+	}
+
+	b = binary.AppendUvarint(b, uint64(field<<3|protoWireBytes))
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// appendProtoDoubleField appends a double field with the given number.
+func appendProtoDoubleField(b []byte, field int, value float64) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|protoWireFixed64))
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(value))
+}
+
+// EntryReader decodes a stream of entries written in FormatProtobuf.
+type EntryReader struct {
+	r *bufio.Reader
+}
+
+// NewEntryReader returns a reader decoding the length-delimited protobuf entries of r.
+func NewEntryReader(r io.Reader) *EntryReader {
+	return &EntryReader{r: bufio.NewReader(r)}
+}
+
+// Next decodes the next entry. It returns io.EOF at the end of the stream and io.ErrUnexpectedEOF if the
+// stream ends within an entry.
+func (er *EntryReader) Next() (Entry, error) {
+	size, err := binary.ReadUvarint(er.r)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if size > protoMaxMessageSize {
+		return Entry{}, errInvalidProtobuf
+	}
+
+	msg := make([]byte, size)
+	_, err = io.ReadFull(er.r, msg)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return decodeProtobufEntry(msg)
+}
+
+// decodeProtobufEntry decodes a single Entry message. Unknown fields are skipped.
+func decodeProtobufEntry(msg []byte) (Entry, error) {
+	var e Entry
+
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return Entry{}, errInvalidProtobuf
+		}
+		msg = msg[n:]
+
+		field := int(key >> 3)
+		switch key & 7 {
+		case protoWireVarint:
+			value, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return Entry{}, errInvalidProtobuf
+			}
+			msg = msg[n:]
+
+			switch field {
+			case protoFieldTime:
+				e.Time = time.Unix(0, int64(value))
+			case protoFieldGoroutine:
+				e.Goroutine = value
+			}
+		case protoWireFixed64:
+			if len(msg) < 8 {
+				return Entry{}, errInvalidProtobuf
+			}
+			value := math.Float64frombits(binary.LittleEndian.Uint64(msg))
+			msg = msg[8:]
+
+			switch field {
+			case protoFieldRuntimeSeconds:
+				e.RuntimeSeconds = value
+			case protoFieldStepSeconds:
+				e.StepSeconds = value
+			}
+		case protoWireBytes:
+			length, n := binary.Uvarint(msg)
+			if n <= 0 || length > uint64(len(msg)-n) {
+				return Entry{}, errInvalidProtobuf
+			}
+			value := string(msg[n : n+int(length)])
+			msg = msg[n+int(length):]
+
+			switch field {
+			case protoFieldLevel:
+				e.Level = value
+			case protoFieldComponent:
+				e.Component = value
+			case protoFieldMessage:
+				e.Message = value
+			case protoFieldVersion:
+				e.LoggerVersion = value
+			case protoFieldFingerprint:
+				e.Fingerprint = value
+			case protoFieldCommit:
+				e.Commit = value
+			case protoFieldBuildTime:
+				e.BuildTime = value
+			}
+		case protoWireFixed32:
+			if len(msg) < 4 {
+				return Entry{}, errInvalidProtobuf
+			}
+			msg = msg[4:]
+		default:
+			return Entry{}, errInvalidProtobuf
+		}
+	}
+
+	return e, nil
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProtobufRoundTrip(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 10, 0, 0, 123456789, time.UTC)
+	entries := []logEntry{
+		{time: ts, level: LevelInfo, content: "minimal"},
+		{
+			time:           ts.Add(time.Second),
+			level:          LevelError,
+			component:      "billing",
+			content:        "with fields",
+			fields:         sortedFields(map[string]interface{}{"id": 42}),
+			runtime:        1.5,
+			step:           0.25,
+			includeRuntime: true,
+			includeStep:    true,
+			version:        Version,
+			fingerprint:    "abc",
+			goroutineID:    7,
+			commit:         "abc1234",
+			buildTime:      "2023-01-01T00:00:00Z",
+		},
+	}
+	expected := []Entry{
+		{Time: ts, Level: LevelInfo, Message: "minimal"},
+		{
+			Time:           ts.Add(time.Second),
+			Level:          LevelError,
+			Component:      "billing",
+			Message:        "with fields id=42",
+			RuntimeSeconds: 1.5,
+			StepSeconds:    0.25,
+			LoggerVersion:  Version,
+			Fingerprint:    "abc",
+			Goroutine:      7,
+			Commit:         "abc1234",
+			BuildTime:      "2023-01-01T00:00:00Z",
+		},
+	}
+
+	var stream strings.Builder
+	for _, e := range entries {
+		stream.WriteString(formatProtobuf(e))
+	}
+
+	reader := NewEntryReader(strings.NewReader(stream.String()))
+	for i, want := range expected {
+		got, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Time.Equal(want.Time) {
+			t.Errorf("entry %d: expected time %s, got %s", i, want.Time, got.Time)
+		}
+		got.Time = want.Time
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	_, err := reader.Next()
+	if err != io.EOF {
+		t.Errorf("expected io.EOF at the end, got %v", err)
+	}
+}
+
+func TestProtobufOutput(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatProtobuf)
+
+	Info("first")
+	Warning("second")
+
+	reader := NewEntryReader(buf)
+	for _, want := range []string{"first", "second"} {
+		entry, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Message != want {
+			t.Errorf("expected %q, got %q", want, entry.Message)
+		}
+	}
+}
+
+func TestProtobufTruncated(t *testing.T) {
+	encoded := formatProtobuf(logEntry{time: time.Now(), level: LevelInfo, content: "truncated"})
+
+	_, err := NewEntryReader(strings.NewReader(encoded[:len(encoded)-2])).Next()
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
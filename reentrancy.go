@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// activeHooks is the number of hooks currently running, so the goroutine ID only has to be looked up while
+// a hook runs.
+var activeHooks atomic.Int64
+
+var hookGoroutinesMu sync.Mutex
+var hookGoroutines = map[uint64]int{}
+
+// runHook runs fn, which calls a user supplied hook like the error handler, and marks the current goroutine
+// as running a hook while it does. Entries logged by the hook are written by writeFallback instead.
+// A panic of the hook is recovered and passed to defaultErrorHandler, not to the hook that panicked, so it
+// neither reaches the caller of the logging function nor the asynchronous writer.
+func runHook(fn func()) {
+	id := goroutineID()
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			defaultErrorHandler(fmt.Errorf("hook panicked: %v", r))
+		}
+	}()
+
+	activeHooks.Add(1)
+	hookGoroutinesMu.Lock()
+	hookGoroutines[id]++
+	hookGoroutinesMu.Unlock()
+
+	defer func() {
+		hookGoroutinesMu.Lock()
+		hookGoroutines[id]--
+		if hookGoroutines[id] <= 0 {
+			delete(hookGoroutines, id)
+		}
+		hookGoroutinesMu.Unlock()
+		activeHooks.Add(-1)
+	}()
+
+	fn()
+}
+
+// insideHook reports whether the current goroutine is running a hook.
+func insideHook() bool {
+	if activeHooks.Load() == 0 {
+		return false
+	}
+
+	id := goroutineID()
+
+	hookGoroutinesMu.Lock()
+	defer hookGoroutinesMu.Unlock()
+
+	return hookGoroutines[id] > 0
+}
+
+// writeFallback writes an entry logged from within a hook to os.Stderr in the text format.
+// It bypasses the log files, outputs and hooks, so a hook that logs can neither recurse infinitely nor
+// deadlock on the locks held while the hook runs.
+func writeFallback(level string, component string, content string, fields map[string]interface{}) {
+	e := logEntry{
+		time:      now(),
+		level:     level,
+		component: component,
+		content:   maskURLCredentials(content),
+		fields:    sortedFields(fields),
+	}
+
+	_, _ = os.Stderr.WriteString(formatText(e))
+}
@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// policies for a full queue of the remote shipping, see RemoteOverflowPolicy
+const (
+	// RemoteDropOldest drops the oldest queued entry to make room for the new one.
+	RemoteDropOldest = "drop_oldest"
+
+	// RemoteBlock makes logging wait until there's room in the queue.
+	RemoteBlock = "block"
+)
+
+// RemoteEndpoint is the URL every entry of the main log is additionally sent to. The entries are queued and
+// POSTed in batches in the background as a JSON object of the form {"entries": ["...", "..."]}, with each entry
+// formatted like in the log file. The entries are written to the log file regardless, so a failing endpoint only
+// causes the failure to be passed to the error handler and the batch to be dropped.
+// An empty endpoint disables the shipping, which is the default.
+// Assigning it is only safe before the first entry is logged, use SetRemoteEndpoint afterwards.
+var RemoteEndpoint = ""
+
+// RemoteBatchSize is the maximum number of entries sent in a single request to the RemoteEndpoint.
+// It's read when the first entry is shipped.
+var RemoteBatchSize = 100
+
+// RemoteFlushInterval is the interval in which the queued entries are sent, even if the batch isn't full.
+// It's read when the first entry is shipped.
+var RemoteFlushInterval = 5 * time.Second
+
+// RemoteQueueSize is the maximum number of entries queued for the RemoteEndpoint. When it's reached,
+// RemoteOverflowPolicy decides what happens. It's read when the first entry is shipped.
+var RemoteQueueSize = 10000
+
+// RemoteOverflowPolicy is the policy applied when the queue of the remote shipping is full, either
+// RemoteDropOldest, which is the default, or RemoteBlock.
+var RemoteOverflowPolicy = RemoteDropOldest
+
+var remoteClient = createHttpClient()
+
+var remoteOnce sync.Once
+var remoteQueue chan string
+var remoteFlush chan chan struct{}
+var remoteStarted atomic.Bool
+var remoteDropped atomic.Uint64
+
+// SetRemoteEndpoint sets the URL every entry of the main log is additionally sent to, see RemoteEndpoint.
+// An empty endpoint disables the shipping.
+func SetRemoteEndpoint(endpoint string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	RemoteEndpoint = strings.TrimSpace(endpoint)
+}
+
+// remotePayload is the JSON body of a request to the RemoteEndpoint.
+type remotePayload struct {
+	Entries []string `json:"entries"`
+}
+
+// ShipLog queues the formatted entry for the RemoteEndpoint. Entries of the main log are shipped automatically,
+// so it's only needed for entries that didn't go through the logger.
+func ShipLog(entry string) {
+	if currentSettings().remoteEndpoint == "" {
+		return
+	}
+
+	remoteOnce.Do(startRemoteShipper)
+
+	if RemoteOverflowPolicy == RemoteBlock {
+		remoteQueue <- entry
+		return
+	}
+
+	for {
+		select {
+		case remoteQueue <- entry:
+			return
+		default:
+		}
+
+		// the queue is full, drop the oldest entry
+		select {
+		case <-remoteQueue:
+			remoteDropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Flush sends all entries queued for the RemoteEndpoint and returns when they were sent.
+// It should be called before the application exits to not lose the queued entries.
+func Flush() {
+	if !remoteStarted.Load() {
+		return
+	}
+
+	done := make(chan struct{})
+	remoteFlush <- done
+	<-done
+}
+
+// startRemoteShipper creates the queue and starts the background worker sending it.
+func startRemoteShipper() {
+	batchSize := RemoteBatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	interval := RemoteFlushInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	remoteQueue = make(chan string, RemoteQueueSize)
+	remoteFlush = make(chan chan struct{})
+
+	go runRemoteShipper(batchSize, interval)
+	remoteStarted.Store(true)
+}
+
+// runRemoteShipper collects the queued entries and sends them in batches of the given size, at the latest after
+// the given interval.
+func runRemoteShipper(batchSize int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var batch []string
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		dropped := remoteDropped.Swap(0)
+		if dropped > 0 {
+			reportError(fmt.Errorf("dropped %d entries for the remote endpoint because the queue was full", dropped))
+			logLifecycleEvent(EventBufferOverflowed, map[string]interface{}{"sink": "remote", "dropped": dropped})
+		}
+
+		err := shipBatch(batch)
+		if err != nil {
+			reportError(fmt.Errorf("could not ship %d entries to remote endpoint: %w", len(batch), err))
+		}
+		batch = nil
+	}
+
+	add := func(entry string) {
+		batch = append(batch, entry)
+		if len(batch) >= batchSize {
+			send()
+		}
+	}
+
+	for {
+		select {
+		case entry := <-remoteQueue:
+			add(entry)
+		case <-ticker.C:
+			send()
+		case done := <-remoteFlush:
+			// take everything that is queued right now
+			for drained := false; !drained; {
+				select {
+				case entry := <-remoteQueue:
+					add(entry)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(done)
+		}
+	}
+}
+
+// shipBatch sends the formatted entries to the RemoteEndpoint in a single request and waits for the response.
+// It returns an error if the request failed or the endpoint didn't respond with a 2xx status code.
+func shipBatch(entries []string) error {
+	endpoint := currentSettings().remoteEndpoint
+	if endpoint == "" {
+		return nil
+	}
+
+	payload := remotePayload{Entries: make([]string, len(entries))}
+	for i, entry := range entries {
+		payload.Entries[i] = strings.TrimSuffix(entry, "\n")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := remoteClient.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("remote endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
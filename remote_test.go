@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// remoteServer records the payloads POSTed to it.
+type remoteServer struct {
+	mu       sync.Mutex
+	status   int
+	payloads []remotePayload
+}
+
+func (s *remoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload remotePayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.payloads = append(s.payloads, payload)
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+	}
+}
+
+// received returns the payloads received so far.
+func (s *remoteServer) received() []remotePayload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]remotePayload(nil), s.payloads...)
+}
+
+// useRemoteServer ships the entries to a new test server for the duration of the test.
+// The shipper reads its settings only once, so every test uses batches of 3 entries and no periodic flush.
+func useRemoteServer(t *testing.T) *remoteServer {
+	t.Helper()
+
+	s := &remoteServer{}
+	server := httptest.NewServer(s)
+	RemoteBatchSize = 3
+	RemoteFlushInterval = time.Hour
+	SetRemoteEndpoint(server.URL)
+	t.Cleanup(func() {
+		Flush()
+		SetRemoteEndpoint("")
+		server.Close()
+	})
+
+	return s
+}
+
+func TestRemoteShipping(t *testing.T) {
+	dir := setupLogDir(t)
+	s := useRemoteServer(t)
+
+	Info("shipped entry")
+	Flush()
+
+	payloads := s.received()
+	if len(payloads) != 1 || len(payloads[0].Entries) != 1 {
+		t.Fatalf("expected a single entry, got %v", payloads)
+	}
+	if !strings.HasSuffix(payloads[0].Entries[0], "INFO shipped entry") {
+		t.Errorf("expected the formatted entry without a newline, got %q", payloads[0].Entries[0])
+	}
+	if lines := readMainLog(t, dir); len(lines) != 1 {
+		t.Errorf("expected the entry in the log file as well, got %q", lines)
+	}
+}
+
+func TestRemoteShippingFailure(t *testing.T) {
+	dir := setupLogDir(t)
+	s := useRemoteServer(t)
+	s.status = http.StatusInternalServerError
+	errs := useErrorHandler(t)
+
+	Info("rejected entry")
+	Flush()
+
+	if len(*errs) != 1 || !strings.Contains((*errs)[0].Error(), "status 500") {
+		t.Errorf("expected the failure to be reported, got %v", *errs)
+	}
+	if lines := readMainLog(t, dir); len(lines) != 1 || !strings.HasSuffix(lines[0], "INFO rejected entry") {
+		t.Errorf("expected the entry in the log file regardless, got %q", lines)
+	}
+}
+
+func TestRemoteBatching(t *testing.T) {
+	setupLogDir(t)
+	s := useRemoteServer(t)
+
+	for i := 0; i < 7; i++ {
+		Info("batched entry")
+	}
+
+	// full batches are sent right away
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.received()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 full batches, got %v", s.received())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// the rest is sent by Flush
+	Flush()
+	if len(remoteQueue) != 0 {
+		t.Errorf("expected an empty queue after Flush, got %d entries", len(remoteQueue))
+	}
+
+	payloads := s.received()
+	if len(payloads) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(payloads))
+	}
+	for i, expected := range []int{3, 3, 1} {
+		if len(payloads[i].Entries) != expected {
+			t.Errorf("expected %d entries in batch %d, got %d", expected, i, len(payloads[i].Entries))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// repeatMaxKeys is the number of distinct messages tracked by the repeat suppression. When it's exceeded,
+// the least recently repeated message is summarized and forgotten.
+const repeatMaxKeys = 256
+
+// repeatSummaryPrefix starts the summary of suppressed repetitions, which is never suppressed itself.
+const repeatSummaryPrefix = "last message repeated "
+
+// repeatState tracks the repetitions of a message within the current window.
+type repeatState struct {
+	key     uint64
+	lg      *Logger
+	level   string
+	content string
+	count   int
+	timer   *time.Timer
+	elem    *list.Element
+}
+
+var repeatMu sync.Mutex
+var repeatWindow time.Duration
+var repeatStates = map[uint64]*repeatState{}
+var repeatLRU = list.New()
+
+// SetRepeatSuppression enables the suppression of repeated messages. When a message is logged again with the
+// same level and component within the given window after its first occurrence, the repetition is not written.
+// Once the window has passed, a single entry "last message repeated N times" is written at the same level
+// instead, with the repeated message and its fingerprint (see Fingerprint) as the fields repeated_message and
+// repeated_fingerprint, and the next occurrence of the message is written again and starts a new window.
+// A window of 0 disables the suppression, which is the default. FATAL messages are never suppressed.
+func SetRepeatSuppression(window time.Duration) {
+	repeatMu.Lock()
+	repeatWindow = window
+	repeatMu.Unlock()
+
+	if window <= 0 {
+		flushRepeats()
+	}
+}
+
+// suppressRepeat reports whether the message is a repetition within the window and should not be written.
+func suppressRepeat(lg *Logger, level string, component string, content string) bool {
+	if strings.HasPrefix(content, repeatSummaryPrefix) {
+		return false
+	}
+
+	repeatMu.Lock()
+
+	if repeatWindow <= 0 {
+		repeatMu.Unlock()
+		return false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(level + "\x00" + component + "\x00" + content))
+	key := h.Sum64()
+
+	if state, ok := repeatStates[key]; ok {
+		state.count++
+		repeatLRU.MoveToFront(state.elem)
+		repeatMu.Unlock()
+		return true
+	}
+
+	state := &repeatState{key: key, lg: lg, level: level, content: content}
+	state.elem = repeatLRU.PushFront(state)
+	state.timer = time.AfterFunc(repeatWindow, func() {
+		repeatMu.Lock()
+		forgotten := forgetRepeat(state)
+		repeatMu.Unlock()
+
+		if forgotten {
+			state.summarize()
+		}
+	})
+	repeatStates[key] = state
+
+	// forget the least recently repeated message if too many are tracked
+	var evicted *repeatState
+	if repeatLRU.Len() > repeatMaxKeys {
+		evicted = repeatLRU.Back().Value.(*repeatState)
+		evicted.timer.Stop()
+		forgetRepeat(evicted)
+	}
+	repeatMu.Unlock()
+
+	if evicted != nil {
+		evicted.summarize()
+	}
+
+	return false
+}
+
+// forgetRepeat removes the state if it's still tracked and reports whether it was. repeatMu must be held.
+func forgetRepeat(state *repeatState) bool {
+	if repeatStates[state.key] != state {
+		return false
+	}
+
+	delete(repeatStates, state.key)
+	repeatLRU.Remove(state.elem)
+	return true
+}
+
+// summarize writes the summary of the suppressed repetitions, if there were any. As the summaries of several
+// messages may be interleaved with other entries, the summary holds the repeated message and its fingerprint.
+func (state *repeatState) summarize() {
+	if state.count == 0 {
+		return
+	}
+
+	fields := map[string]interface{}{
+		"repeated_message":     state.content,
+		"repeated_fingerprint": Fingerprint(state.content),
+	}
+	state.lg.lf(state.level, fmt.Sprintf("%s%d times", repeatSummaryPrefix, state.count), fields)
+}
+
+// flushRepeats writes the summaries of all tracked messages and forgets them.
+func flushRepeats() {
+	repeatMu.Lock()
+	var states []*repeatState
+	for _, state := range repeatStates {
+		state.timer.Stop()
+		states = append(states, state)
+	}
+	repeatStates = map[uint64]*repeatState{}
+	repeatLRU.Init()
+	repeatMu.Unlock()
+
+	for _, state := range states {
+		state.summarize()
+	}
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepeatSuppression(t *testing.T) {
+	buf := captureOutput(t)
+	SetRepeatSuppression(time.Hour)
+	t.Cleanup(func() {
+		SetRepeatSuppression(0)
+	})
+
+	for i := 0; i < 50; i++ {
+		Error("connection refused")
+	}
+	Error("other message")
+
+	// disabling the suppression writes the summary
+	SetRepeatSuppression(0)
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 3 {
+		t.Fatalf("expected the message, the other message and the summary, got %q", lines)
+	}
+	if countContaining(lines, "ERROR connection refused") != 1 {
+		t.Errorf("expected the repeated message once, got %q", lines)
+	}
+	if !strings.Contains(lines[2], "ERROR last message repeated 49 times") || !strings.Contains(lines[2], `repeated_message="connection refused"`) {
+		t.Errorf("expected the summary of 49 repetitions, got %q", lines[2])
+	}
+}
+
+func TestRepeatSuppressionWindow(t *testing.T) {
+	dir := setupLogDir(t)
+	SetRepeatSuppression(50 * time.Millisecond)
+	t.Cleanup(func() {
+		SetRepeatSuppression(0)
+	})
+
+	for i := 0; i < 10; i++ {
+		Warning("flapping")
+	}
+
+	// the summary is written once the window has passed
+	deadline := time.Now().Add(5 * time.Second)
+	for countContaining(readMainLog(t, dir), "last message repeated 9 times") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the summary after the window, got %q", readMainLog(t, dir))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the next occurrence starts a new window
+	Warning("flapping")
+	if n := countContaining(readMainLog(t, dir), "WARNING flapping"); n != 2 {
+		t.Errorf("expected the message to be written again, got it %d times", n)
+	}
+}
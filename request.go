@@ -1,20 +1,162 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/oschwald/geoip2-golang"
+	"github.com/valyala/fasthttp"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// GeoIPDB is the GeoIP database used to enrich logged requests. Default: nil, which disables the lookup.
+// Assigning it is only safe before the first request is logged, use LoadGeoIPDB afterwards.
 var GeoIPDB *geoip2.Reader
 
+var includeClientLocalTime = false
+var includeRequestGeo = false
+
+var requestLogLevel = LevelInfo
+
+var requestLogDir = ""
+var requestLogPrefix = "requests"
+
+// requestCSVMu serializes the writes to the request CSV files and the rotation of their segments, so the header
+// is written only once per file and no segment is removed while it's written.
+var requestCSVMu sync.Mutex
+
+// SetRequestLogLevel sets the level of the request entries in the main log. Default: INFO
+// It applies to LogRequest as well as LogSimpleRequest and allows to suppress request entries independently
+// by setting a level below the minimum log level, or to elevate them e.g. to NOTICE.
+// The level may be one of the LevelAliases; an unknown level is ignored and leaves the current setting unchanged.
+func SetRequestLogLevel(level string) {
+	level, ok := resolveLevel(level)
+	if !ok {
+		log.Println("LOGGER: Invalid request log level: " + level)
+		return
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	requestLogLevel = level
+}
+
+// SetIncludeClientLocalTime sets whether logged requests include the time in the client's timezone.
+// The timezone is taken from the GeoIP lookup (Request.Timezone); if it's unknown or invalid,
+// ClientLocalTime stays empty.
+func SetIncludeClientLocalTime(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	includeClientLocalTime = include
+}
+
+// SetIncludeRequestGeo sets whether the request entries in the main log include the city and country code of
+// the client, e.g. "(GET) /api <- UA @ 1.2.3.4 [Berlin, DE]". They are only added if the GeoIP lookup found
+// them, see GeoIPDB.
+func SetIncludeRequestGeo(include bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	includeRequestGeo = include
+}
+
+// requestGeoSuffix returns the location of the client for the main log entry of the request,
+// or an empty string if it's unknown.
+func requestGeoSuffix(req *Request) string {
+	var parts []string
+	if req.City != "" {
+		parts = append(parts, req.City)
+	}
+
+	if req.CountryCode != "" {
+		parts = append(parts, req.CountryCode)
+	} else if req.Country != "" && req.Country != "Unknown" {
+		parts = append(parts, req.Country)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+// clientLocalTime returns t in the given IANA timezone formatted as RFC 3339,
+// or an empty string if the timezone is unknown.
+func clientLocalTime(t time.Time, timezone string) string {
+	if timezone == "" {
+		return ""
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return ""
+	}
+
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// SetRequestLogDir sets the directory of the separate request log files.
+// An empty string, the default, stores them in LogDir next to the main log.
+func SetRequestLogDir(dir string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	requestLogDir = strings.TrimSpace(dir)
+}
+
+// SetRequestLogPrefix sets the base name of the separate request log files. Default: requests
+// The files are named <prefix>-YYYY-MM-DD.csv and <prefix>-simple-YYYY-MM-DD.csv for LogSimpleRequest.
+func SetRequestLogPrefix(prefix string) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		prefix = "requests"
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	requestLogPrefix = prefix
+}
+
+// requestLogBase returns the path of the request log for the given date without extension,
+// e.g. ./logs/requests-2023-01-01. The name is extended by the given infix if it's not empty,
+// e.g. ./logs/requests-simple-2023-01-01. The request log directory is created if it doesn't exist.
+func requestLogBase(infix string, date string) string {
+	s := currentSettings()
+	dir := s.requestLogDir
+	if dir == "" {
+		reconfigureMu.RLock()
+		dir = LogDir
+		reconfigureMu.RUnlock()
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		reportError(fmt.Errorf("could not create request log directory: %w", err))
+	}
+
+	name := s.requestLogPrefix + "-"
+	if infix != "" {
+		name += infix + "-"
+	}
+
+	return dir + "/" + name + date
+}
+
 type Request struct {
 	// ConnectionTime is the connection time of the client.
 	// See https://pkg.go.dev/github.com/valyala/fasthttp#RequestCtx.ConnTime
@@ -95,6 +237,33 @@ type Request struct {
 	// SubdivisionCode is the subdivision code of the client.
 	// Examples: BE, NY, ENG, IDF, 13, 31
 	SubdivisionCode string `json:"subdivision_code"`
+
+	// Count is the number of identical requests this record stands for.
+	// It's 1 unless request deduplication collapsed repeated requests into this record.
+	// See SetRequestDedupWindow.
+	Count uint64 `json:"count"`
+
+	// ClientLocalTime is the time the request was logged at, in the timezone of the client.
+	// It's only set if SetIncludeClientLocalTime is enabled and the timezone of the client is known.
+	// Examples: 2023-01-01T13:04:05+01:00, 2023-01-01T07:04:05-05:00
+	ClientLocalTime string `json:"client_local_time"`
+
+	// Extra holds custom metadata of the application, e.g. the tenant or the name of the route.
+	// In the request log, it's written as a single JSON-encoded column with sorted keys.
+	// Examples: {"route":"user.show","tenant":"42"}
+	Extra map[string]string `json:"extra,omitempty"`
+
+	// StatusCode is the status code of the response. It's 0 if the response isn't known.
+	// Examples: 200, 304, 404, 500
+	StatusCode int `json:"status_code"`
+
+	// ResponseBytes is the size of the response body in bytes.
+	// Examples: 0, 512, 1048576
+	ResponseBytes int64 `json:"response_bytes"`
+
+	// Duration is the time it took to handle the request.
+	// Examples: 1.2ms, 350µs, 2.5s
+	Duration time.Duration `json:"duration"`
 }
 
 func New() *Request {
@@ -127,43 +296,77 @@ func GetCSVHeader() []string {
 		"subdivision_code",
 		"connection_id",
 		"connection_seq",
+		"count",
+		"client_local_time",
+		"extra",
+		"status_code",
+		"response_bytes",
+		"duration",
 	}
 }
 
+// ToCSV returns the request as a CSV row in the column order of GetCSVHeader, terminated by a newline.
+// Fields are quoted as described in RFC 4180.
 func (r *Request) ToCSV() string {
-	return r.ConnectionTime + "," +
-		r.Method + "," +
-		r.Path + "," +
-		r.IP + "," +
-		r.Address + "," +
-		r.UserAgent + "," +
-		r.Referer + "," +
-		r.RequestedHost + "," +
-		r.Continent + "," +
-		r.Country + "," +
-		r.CountryCode + "," +
-		r.City + "," +
-		fmt.Sprintf("%.12f", r.Latitude) + "," +
-		fmt.Sprintf("%.12f", r.Longitude) + "," +
-		r.Timezone + "," +
-		r.PostalCode + "," +
-		r.Subdivision + "," +
-		r.SubdivisionCode + "," +
-		strconv.FormatUint(r.ConnectionID, 10) + "," +
-		strconv.FormatUint(r.ConnectionSeq, 10) + "\n"
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	// writing to a bytes.Buffer doesn't fail
+	_ = w.Write(r.csvRecord())
+	w.Flush()
+
+	return buf.String()
+}
+
+// csvRecord returns the fields of the request in the column order of GetCSVHeader.
+func (r *Request) csvRecord() []string {
+	return []string{
+		r.ConnectionTime,
+		r.Method,
+		r.Path,
+		r.IP,
+		r.Address,
+		r.UserAgent,
+		r.Referer,
+		r.RequestedHost,
+		r.Continent,
+		r.Country,
+		r.CountryCode,
+		r.City,
+		fmt.Sprintf("%.12f", r.Latitude),
+		fmt.Sprintf("%.12f", r.Longitude),
+		r.Timezone,
+		r.PostalCode,
+		r.Subdivision,
+		r.SubdivisionCode,
+		strconv.FormatUint(r.ConnectionID, 10),
+		strconv.FormatUint(r.ConnectionSeq, 10),
+		strconv.FormatUint(r.Count, 10),
+		r.ClientLocalTime,
+		r.extraJSON(),
+		strconv.Itoa(r.StatusCode),
+		strconv.FormatInt(r.ResponseBytes, 10),
+		r.Duration.String(),
+	}
+}
+
+// extraJSON returns Extra encoded as JSON, or an empty string if it's nil.
+// encoding/json sorts the keys of maps, so the encoding is deterministic.
+func (r *Request) extraJSON() string {
+	if r.Extra == nil {
+		return ""
+	}
+
+	// a map of strings can always be encoded
+	b, _ := json.Marshal(r.Extra)
+	return string(b)
 }
 
+// LogRequestFromFiber logs the request of the given Fiber v2 context. For Fiber v3, use LogRequestFromFiberV3.
 func LogRequestFromFiber(c fiber.Ctx) {
 	// Create a new request
 	req := New()
 
-	// Set the connection time
-	connTime := time.Now().String()
-	if c.Context() != nil {
-		connTime = c.Context().ConnTime().String()
-	}
-	req.ConnectionTime = connTime
-
 	// Set the method
 	req.Method = c.Method()
 
@@ -171,129 +374,412 @@ func LogRequestFromFiber(c fiber.Ctx) {
 	req.Path = c.Path()
 
 	// Set the IP
-	var rawIP net.IP
-	ip := c.IP()
+	// The first entry of c.IPs() comes from the X-Forwarded-For header, which may contain arbitrary client
+	// supplied data, so it's not necessarily a valid IP.
+	req.IP = c.IP()
 	if len(c.IPs()) > 0 {
-		ip = c.IPs()[0]
+		req.IP = c.IPs()[0]
 	}
-	req.IP = ip
-	rawIP = net.ParseIP(ip)
 
-	if GeoIPDB != nil {
-		record, err := GeoIPDB.City(rawIP)
-		if err != nil {
-			log.Fatal(err)
-		}
+	// Set the user agent
+	req.UserAgent = c.Get(fiber.HeaderUserAgent)
 
-		continent := "Unknown"
-		if record.Continent.Names["en"] != "" {
-			continent = record.Continent.Names["en"]
-		}
-		req.Continent = continent
+	// Set the referer
+	req.Referer = c.Get(fiber.HeaderReferer)
 
-		country := "Unknown"
-		if record.Country.Names["en"] != "" {
-			country = record.Country.Names["en"]
-		}
-		req.Country = country
-
-		req.CountryCode = record.Country.IsoCode
-		req.City = record.City.Names["en"]
-		req.Latitude = record.Location.Latitude
-		req.Longitude = record.Location.Longitude
-		req.Timezone = record.Location.TimeZone
-		req.PostalCode = record.Postal.Code
-
-		subdivision := "Unknown"
-		if len(record.Subdivisions) > 0 && record.Subdivisions[0].Names["en"] != "" {
-			subdivision = record.Subdivisions[0].Names["en"]
-		}
-		req.Subdivision = subdivision
+	// Set the connection time, address, ID, sequence, requested host and the response
+	setFastHTTPInfo(req, c.Context(), c.IP())
 
-		subdivisionCode := "Unknown"
-		if len(record.Subdivisions) > 0 && record.Subdivisions[0].IsoCode != "" {
-			subdivisionCode = record.Subdivisions[0].IsoCode
-		}
-		req.SubdivisionCode = subdivisionCode
+	enrich(req)
+
+	// Log the request
+	LogRequest(req)
+}
+
+// LogRequestFromHTTP logs the given request of the net/http package like LogRequestFromFiber does for Fiber.
+func LogRequestFromHTTP(r *http.Request) {
+	LogRequest(requestFromHTTP(r))
+}
+
+// requestFromHTTP creates a Request from the given request of the net/http package.
+func requestFromHTTP(r *http.Request) *Request {
+	// Create a new request
+	req := New()
+
+	// Set the connection time
+	req.ConnectionTime = time.Now().String()
+
+	// Set the method
+	req.Method = r.Method
+
+	// Set the path
+	req.Path = r.URL.Path
+
+	// Set the IP
+	// Like for Fiber, the first entry of the X-Forwarded-For header is preferred. It may contain arbitrary client
+	// supplied data, so it's not necessarily a valid IP.
+	req.IP = remoteIP(r.RemoteAddr)
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		req.IP = strings.TrimSpace(strings.Split(forwarded, ",")[0])
 	}
 
 	// Set the address
-	remoteAddr := c.IP()
-	if c.Context() != nil {
-		remoteAddr = c.Context().RemoteAddr().String()
-	}
-	req.Address = remoteAddr
+	req.Address = r.RemoteAddr
 
 	// Set the user agent
-	req.UserAgent = c.Get(fiber.HeaderUserAgent)
+	req.UserAgent = r.UserAgent()
 
 	// Set the referer
-	req.Referer = c.Get(fiber.HeaderReferer)
+	req.Referer = r.Referer()
 
-	// Set the connection ID
-	req.ConnectionID = c.Context().ConnID()
+	// Set the requested host
+	req.RequestedHost = r.Host
 
-	// Set the connection sequence
-	req.ConnectionSeq = c.Context().ConnRequestNum()
+	enrich(req)
 
-	// Set the requested host
-	req.RequestedHost = string(c.Context().Host())
+	return req
+}
 
-	// Log the request
-	LogRequest(req)
+// remoteIP returns the IP of the given remote address, which may or may not contain a port.
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
 }
 
-func LogRequest(req *Request) {
-	if (!LogRequestsSeparately) || (LogRequestsSeparately && !HideRequestsFromMainLog) {
-		Log(LevelInfo, fmt.Sprintf("(%s) %s <- %s @ %s", req.Method, req.Path, req.UserAgent, req.IP))
+// setFastHTTPInfo sets the fields of the request that are taken from the fasthttp context the Fiber adapters
+// are based on. If ctx is nil, the connection time is the current time and the address is the given IP.
+// The response and the duration are those at the time of the call, so the adapters should be called after the
+// request was handled.
+func setFastHTTPInfo(req *Request, ctx *fasthttp.RequestCtx, ip string) {
+	if ctx == nil {
+		req.ConnectionTime = time.Now().String()
+		req.Address = ip
+		return
 	}
 
-	if LogRequestsSeparately {
-		// get the current date
-		t := time.Now()
+	req.ConnectionTime = ctx.ConnTime().String()
+	req.Address = ctx.RemoteAddr().String()
+	req.ConnectionID = ctx.ConnID()
+	req.ConnectionSeq = ctx.ConnRequestNum()
+	req.RequestedHost = string(ctx.Host())
+	req.StatusCode = ctx.Response.StatusCode()
+	req.ResponseBytes = int64(len(ctx.Response.Body()))
+	req.Duration = time.Since(ctx.Time())
+}
 
-		// format time to YYYY-MM-DD
-		date := t.Format("2006-01-02")
+// enrich adds the information derived from the IP of the request, which is shared by all adapters.
+func enrich(req *Request) {
+	db := currentSettings().geoIPDB
+	if db != nil {
+		lookupGeoIP(db, req, net.ParseIP(req.IP))
+	}
+}
 
-		// format time to HH:MM:SS
-		//tFormatted := t.Format("2006-01-02 15:04:05.000000")
+// lookupGeoIP sets the location of the client from the given GeoIP database.
+// If the IP couldn't be parsed, the lookup is skipped; if it fails, a warning is logged. In both cases the
+// location is unknown.
+func lookupGeoIP(db *geoip2.Reader, req *Request, rawIP net.IP) {
+	if rawIP == nil {
+		setUnknownGeo(req)
+		return
+	}
 
-		filename := LogDir + "/requests-" + date + ".csv"
+	record, err := db.City(rawIP)
+	if err != nil {
+		Warning("Could not look up IP " + req.IP + " in GeoIP database: " + err.Error())
+		setUnknownGeo(req)
+		return
+	}
 
-		// Add the header if the file doesn't exist
-		if _, err := os.Stat(filename); os.IsNotExist(err) {
-			// Create the file
-			file, err := os.Create(filename)
-			if err != nil {
-				log.Fatal(err)
-			}
+	continent := "Unknown"
+	if record.Continent.Names["en"] != "" {
+		continent = record.Continent.Names["en"]
+	}
+	req.Continent = continent
 
-			// Write the header
-			_, err = file.WriteString(strings.Join(GetCSVHeader(), ",") + "\n")
-			if err != nil {
-				log.Fatal(err)
+	country := "Unknown"
+	if record.Country.Names["en"] != "" {
+		country = record.Country.Names["en"]
+	}
+	req.Country = country
+
+	req.CountryCode = record.Country.IsoCode
+	req.City = record.City.Names["en"]
+	req.Latitude = record.Location.Latitude
+	req.Longitude = record.Location.Longitude
+	req.Timezone = record.Location.TimeZone
+	req.PostalCode = record.Postal.Code
+
+	subdivision := "Unknown"
+	if len(record.Subdivisions) > 0 && record.Subdivisions[0].Names["en"] != "" {
+		subdivision = record.Subdivisions[0].Names["en"]
+	}
+	req.Subdivision = subdivision
+
+	subdivisionCode := "Unknown"
+	if len(record.Subdivisions) > 0 && record.Subdivisions[0].IsoCode != "" {
+		subdivisionCode = record.Subdivisions[0].IsoCode
+	}
+	req.SubdivisionCode = subdivisionCode
+}
+
+// setUnknownGeo sets the location of the client to unknown.
+func setUnknownGeo(req *Request) {
+	req.Continent = "Unknown"
+	req.Country = "Unknown"
+	req.Subdivision = "Unknown"
+	req.SubdivisionCode = "Unknown"
+}
+
+// LogRequest logs the request to the main log and, if LogRequestsSeparately is true, to the request CSV file.
+// In the main log, it's a single line like (GET) /path <- user agent @ ip, or an entry with the message "request"
+// and the fields method, path, ip, user_agent, country and status if the request log level is written as JSON.
+func LogRequest(req *Request) {
+	if disabled.Load() {
+		return
+	}
+
+	req.Path = maskURLCredentials(req.Path)
+	req.Referer = maskURLCredentials(req.Referer)
+
+	s := currentSettings()
+	if (!s.logRequestsSeparately) || (s.logRequestsSeparately && !s.hideRequestsFromMainLog) {
+		if formatForLevel(s.requestLogLevel) == FormatJSON {
+			// log the request as fields, so the main log can be queried by them
+			std.dispatchFields(s.requestLogLevel, "request", requestFields(req))
+		} else {
+			entry := fmt.Sprintf("(%s) %s <- %s @ %s", req.Method, req.Path, req.UserAgent, req.IP)
+			if s.includeRequestGeo {
+				entry += requestGeoSuffix(req)
 			}
-			err = file.Close()
-			if err != nil {
-				log.Fatal(err)
+			if req.StatusCode != 0 {
+				entry += fmt.Sprintf(" -> %d (%d bytes, %s)", req.StatusCode, req.ResponseBytes, req.Duration)
 			}
+			Log(s.requestLogLevel, entry)
+		}
+	}
+
+	if s.logRequestsSeparately {
+		if req.Count == 0 {
+			req.Count = 1
 		}
 
-		// open file requests.csv
-		f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			log.Fatal(err)
+		if s.includeClientLocalTime && req.ClientLocalTime == "" {
+			req.ClientLocalTime = clientLocalTime(time.Now(), req.Timezone)
 		}
 
-		// replace all , with ; in user agent
-		req.UserAgent = strings.ReplaceAll(req.UserAgent, ",", ";")
+		// hold the request back if it's a repetition within the dedup window
+		if dedupRequest(req) {
+			return
+		}
+
+		writeRequestCSV(req)
+	}
+}
+
+// requestFields returns the fields of the request logged in the main log in the JSON format.
+// The response fields are only included if the status code is known.
+func requestFields(req *Request) map[string]interface{} {
+	fields := map[string]interface{}{
+		"method":     req.Method,
+		"path":       req.Path,
+		"ip":         req.IP,
+		"user_agent": req.UserAgent,
+		"country":    req.Country,
+	}
+	if req.StatusCode != 0 {
+		fields["status"] = req.StatusCode
+		fields["response_bytes"] = req.ResponseBytes
+		fields["duration"] = req.Duration
+	}
+
+	return fields
+}
+
+// writeRequestCSV writes the given request to the request log file of the current day.
+func writeRequestCSV(req *Request) {
+	// get the current date
+	t := now()
+
+	// format time to YYYY-MM-DD
+	date := t.Format("2006-01-02")
+
+	appendRequestCSV(requestLogBase("", date), GetCSVHeader(), req.csvRecord())
+}
+
+// simpleRequestCSVHeader is the header of the CSV files written by LogSimpleRequest.
+var simpleRequestCSVHeader = []string{"time", "method", "path", "user_agent", "ip"}
+
+// writeSimpleRequestCSV appends a request logged by LogSimpleRequest to its separate CSV file, writing the
+// header first if the file is new.
+func writeSimpleRequestCSV(method string, path string, userAgent string, ip string) {
+	// get the current date
+	t := now()
+
+	// format time to YYYY-MM-DD
+	date := t.Format("2006-01-02")
+
+	appendRequestCSV(requestLogBase("simple", date), simpleRequestCSVHeader, []string{t.Format(timeLayout()), method, path, userAgent, ip})
+}
+
+// appendRequestCSV appends the record to the current segment of the request log with the given base, writing
+// the header first if the segment is new. The errors and the rotation of the segments are reported once
+// requestCSVMu is released, so the error handler and the hooks may log requests themselves.
+func appendRequestCSV(base string, header []string, record []string) {
+	requestCSVMu.Lock()
+	filename, rotation := requestLogSegment(base, ".csv")
+	errs := appendCSVRecord(filename, header, record)
+	requestCSVMu.Unlock()
+
+	rotation.report()
+	for _, err := range errs {
+		reportError(err)
+	}
+}
+
+// appendCSVRecord appends the record to the CSV file with the given name, writing the header first if the file
+// doesn't exist yet, and returns the errors that occurred. requestCSVMu must be held.
+func appendCSVRecord(filename string, header []string, record []string) []error {
+	var errs []error
+
+	// check if the header has to be written
+	_, err := os.Stat(filename)
+	writeHeader := os.IsNotExist(err)
 
-		entry := req.ToCSV()
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return append(errs, err)
+	}
 
-		// write to file
-		_, err = f.WriteString(entry)
+	w := csv.NewWriter(f)
+	if writeHeader {
+		err = w.Write(header)
 		if err != nil {
-			log.Fatal(err)
+			errs = append(errs, err)
+		}
+	}
+
+	err = w.Write(record)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// write to file
+	w.Flush()
+	err = w.Error()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// close file
+	err = f.Close()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// requestSegmentName returns the file name of the request log segment with the given index.
+// Segment 0 is the base file itself, e.g. requests-2023-01-01.csv, segment 1 is requests-2023-01-01.1.csv.
+func requestSegmentName(base string, ext string, index int) string {
+	if index == 0 {
+		return base + ext
+	}
+
+	return base + "." + strconv.Itoa(index) + ext
+}
+
+// requestSegmentIndexes returns the indexes of all existing segments of the given request log, sorted ascending.
+func requestSegmentIndexes(base string, ext string) []int {
+	var indexes []int
+	if _, err := os.Stat(base + ext); err == nil {
+		indexes = append(indexes, 0)
+	}
+
+	matches, err := filepath.Glob(base + ".*" + ext)
+	if err != nil {
+		return indexes
+	}
+
+	for _, match := range matches {
+		index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(match, base+"."), ext))
+		if err != nil || index < 1 {
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+
+	sort.Ints(indexes)
+	return indexes
+}
+
+// requestLogRotation is a new segment started by requestLogSegment, which is reported once requestCSVMu is
+// released.
+type requestLogRotation struct {
+	from    string
+	to      string
+	removed int
+	errs    []error
+}
+
+// report logs the lifecycle events of the rotation and passes the errors to the error handler.
+// Nothing is reported for a nil rotation.
+func (r *requestLogRotation) report() {
+	if r == nil {
+		return
+	}
+
+	for _, err := range r.errs {
+		reportError(err)
+	}
+	if r.removed > 0 {
+		logLifecycleEvent(EventRetentionDeleted, map[string]interface{}{"files": r.removed})
+	}
+	logLifecycleEvent(EventFileRotated, map[string]interface{}{"from": r.from, "to": r.to})
+}
+
+// requestLogSegment returns the file name of the request log segment that should be written to.
+// If MaxRequestLogSize is 0, the base file name is returned unchanged.
+// Otherwise, a new segment is started once the latest one reached MaxRequestLogSize and the oldest
+// segments are removed to keep at most MaxRequestLogSegments segments per day. In that case, the rotation
+// is returned as well, to be reported by the caller. requestCSVMu must be held.
+func requestLogSegment(base string, ext string) (string, *requestLogRotation) {
+	s := currentSettings()
+	if s.maxRequestLogSize <= 0 {
+		return base + ext, nil
+	}
+
+	indexes := requestSegmentIndexes(base, ext)
+	if len(indexes) == 0 {
+		return base + ext, nil
+	}
+
+	current := indexes[len(indexes)-1]
+	filename := requestSegmentName(base, ext, current)
+	info, err := os.Stat(filename)
+	if err != nil || info.Size() < s.maxRequestLogSize {
+		return filename, nil
+	}
+
+	next := requestSegmentName(base, ext, current+1)
+	rotation := &requestLogRotation{from: filename, to: next}
+
+	// the latest segment is full, remove the oldest segments if the new one would exceed the limit
+	if s.maxRequestLogSegments > 0 && len(indexes) >= s.maxRequestLogSegments {
+		for _, index := range indexes[:len(indexes)-s.maxRequestLogSegments+1] {
+			err = os.Remove(requestSegmentName(base, ext, index))
+			if err != nil {
+				rotation.errs = append(rotation.errs, fmt.Errorf("could not remove request log segment: %w", err))
+			} else {
+				rotation.removed++
+			}
 		}
 	}
+
+	return next, rotation
 }
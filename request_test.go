@@ -0,0 +1,657 @@
+package logger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// enableRequestLog logs requests separately for the duration of the test.
+func enableRequestLog(t *testing.T) {
+	t.Helper()
+
+	SetLogRequestsSeparately(true)
+	t.Cleanup(func() {
+		SetLogRequestsSeparately(false)
+	})
+}
+
+// readCSV returns the records of the given CSV file.
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("could not parse %s: %v", path, err)
+	}
+
+	return records
+}
+
+func TestRequestLogRotatesBySize(t *testing.T) {
+	dir := setupLogDir(t)
+	enableRequestLog(t)
+
+	SetMaxRequestLogSize(1024)
+	t.Cleanup(func() {
+		SetMaxRequestLogSize(0)
+	})
+
+	for i := 0; i < 50; i++ {
+		LogRequest(&Request{Method: "GET", Path: "/rotation", IP: "127.0.0.1", UserAgent: "test"})
+	}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "requests-*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) < 2 {
+		t.Fatalf("expected several segments, got %v", segments)
+	}
+
+	header := GetCSVHeader()
+	rows := 0
+	for _, segment := range segments {
+		records := readCSV(t, segment)
+		if len(records) == 0 || records[0][0] != header[0] || len(records[0]) != len(header) {
+			t.Errorf("segment %s doesn't start with the header", segment)
+			continue
+		}
+		rows += len(records) - 1
+	}
+
+	if rows != 50 {
+		t.Errorf("expected 50 rows in all segments, got %d", rows)
+	}
+}
+
+func TestConcurrentFirstRequests(t *testing.T) {
+	dir := setupLogDir(t)
+	enableRequestLog(t)
+	preserveConfig(t)
+	SetHideRequestsFromMainLog(true)
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			LogRequest(sampleRequest())
+			LogSimpleRequest("GET", "/", "agent", "127.0.0.1")
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	date := now().Format("2006-01-02")
+	files := map[string]string{
+		"requests-" + date + ".csv":        GetCSVHeader()[0],
+		"requests-simple-" + date + ".csv": simpleRequestCSVHeader[0],
+	}
+	for name, firstColumn := range files {
+		records := readCSV(t, filepath.Join(dir, name))
+		headers := 0
+		for _, record := range records {
+			if record[0] == firstColumn {
+				headers++
+			}
+		}
+		if headers != 1 || len(records) != 21 {
+			t.Errorf("expected a single header and 20 rows in %s, got %d headers in %d records", name, headers, len(records))
+		}
+	}
+}
+
+func TestConcurrentRequestLogRotation(t *testing.T) {
+	dir := setupLogDir(t)
+	enableRequestLog(t)
+	preserveConfig(t)
+	SetHideRequestsFromMainLog(true)
+	SetMaxRequestLogSize(2048)
+	SetMaxRequestLogSegments(3)
+
+	var errsMu sync.Mutex
+	var errs []error
+	SetErrorHandler(func(err error) {
+		errsMu.Lock()
+		errs = append(errs, err)
+		errsMu.Unlock()
+	})
+	t.Cleanup(func() {
+		SetErrorHandler(nil)
+	})
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 25; j++ {
+				LogRequest(sampleRequest())
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "requests-*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 3 {
+		t.Errorf("expected 3 segments to be kept, got %v", segments)
+	}
+
+	header := GetCSVHeader()
+	for _, segment := range segments {
+		records := readCSV(t, segment)
+		if len(records) == 0 || records[0][0] != header[0] {
+			t.Errorf("segment %s doesn't start with the header", segment)
+		}
+		for i, record := range records[1:] {
+			if record[0] == header[0] {
+				t.Errorf("segment %s has a second header in line %d", segment, i+2)
+			}
+		}
+
+		// a segment is only continued while it's below the maximum size, so no segment grows much larger
+		info, err := os.Stat(segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() >= 2048+1024 {
+			t.Errorf("expected segment %s to be rotated at 2048 bytes, got %d bytes", segment, info.Size())
+		}
+	}
+}
+
+func TestLogRequestJSONFields(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	LogRequest(&Request{Method: "POST", Path: "/api/users", IP: "10.0.0.1", UserAgent: "curl/8.0", Country: "Germany", StatusCode: 201})
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	expected := map[string]interface{}{
+		"msg":        "request",
+		"level":      LevelInfo,
+		"method":     "POST",
+		"path":       "/api/users",
+		"ip":         "10.0.0.1",
+		"user_agent": "curl/8.0",
+		"country":    "Germany",
+		"status":     float64(201),
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, entry[key])
+		}
+	}
+}
+
+func TestLogSimpleRequestJSONFields(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	LogSimpleRequest("GET", "/api/users", "curl/8.0", "10.0.0.1")
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	expected := map[string]interface{}{
+		"msg":        "request",
+		"level":      LevelInfo,
+		"method":     "GET",
+		"path":       "/api/users",
+		"ip":         "10.0.0.1",
+		"user_agent": "curl/8.0",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, entry[key])
+		}
+	}
+}
+
+func TestRequestLogPath(t *testing.T) {
+	setupLogDir(t)
+	enableRequestLog(t)
+
+	dir := filepath.Join(t.TempDir(), "requests")
+	SetRequestLogDir(dir)
+	SetRequestLogPrefix("access")
+	t.Cleanup(func() {
+		SetRequestLogDir("")
+		SetRequestLogPrefix("")
+	})
+
+	LogRequest(&Request{Method: "GET", Path: "/custom", IP: "127.0.0.1"})
+
+	records := readCSV(t, filepath.Join(dir, "access-"+now().Format("2006-01-02")+".csv"))
+	if len(records) != 2 {
+		t.Fatalf("expected the header and a row, got %d records", len(records))
+	}
+	if records[0][0] != GetCSVHeader()[0] {
+		t.Errorf("expected the header first, got %q", records[0])
+	}
+	if records[1][indexOf(GetCSVHeader(), "path")] != "/custom" {
+		t.Errorf("expected the logged request, got %q", records[1])
+	}
+}
+
+func TestClientLocalTime(t *testing.T) {
+	setupLogDir(t)
+	enableRequestLog(t)
+
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("time zone database not available: " + err.Error())
+	}
+
+	SetIncludeClientLocalTime(true)
+	t.Cleanup(func() {
+		SetIncludeClientLocalTime(false)
+	})
+
+	req := &Request{Method: "GET", Path: "/", IP: "127.0.0.1", Timezone: "Asia/Tokyo"}
+	before := time.Now()
+	LogRequest(req)
+
+	clientTime, err := time.Parse(time.RFC3339, req.ClientLocalTime)
+	if err != nil {
+		t.Fatalf("expected an RFC 3339 client local time, got %q", req.ClientLocalTime)
+	}
+
+	expected := before.In(loc)
+	if clientTime.Format("-07:00") != expected.Format("-07:00") {
+		t.Errorf("expected the offset of Asia/Tokyo, got %s", req.ClientLocalTime)
+	}
+	if diff := clientTime.Sub(expected.Truncate(time.Second)); diff < 0 || diff > 2*time.Second {
+		t.Errorf("expected the server time converted to Asia/Tokyo, got %s for %s", req.ClientLocalTime, expected)
+	}
+
+	// an unknown zone leaves the client local time empty
+	req = &Request{Method: "GET", Path: "/", IP: "127.0.0.1", Timezone: "Nowhere/Unknown"}
+	LogRequest(req)
+	if req.ClientLocalTime != "" {
+		t.Errorf("expected no client local time for an unknown zone, got %q", req.ClientLocalTime)
+	}
+}
+
+func TestRequestLogLevel(t *testing.T) {
+	buf := captureOutput(t)
+	SetMinimumLogLevel(LevelInfo)
+
+	SetRequestLogLevel(LevelDebug)
+	LogSimpleRequest("GET", "/suppressed", "test", "127.0.0.1")
+	LogRequest(&Request{Method: "GET", Path: "/suppressed", IP: "127.0.0.1"})
+	if buf.Len() != 0 {
+		t.Errorf("expected DEBUG requests to be suppressed, got %q", buf.String())
+	}
+
+	SetRequestLogLevel(LevelNotice)
+	t.Cleanup(func() {
+		SetRequestLogLevel(LevelInfo)
+	})
+	LogSimpleRequest("GET", "/elevated", "test", "127.0.0.1")
+	if !strings.Contains(buf.String(), "NOTICE (GET) /elevated") {
+		t.Errorf("expected a NOTICE request entry, got %q", buf.String())
+	}
+
+	SetRequestLogLevel("Warn")
+	LogSimpleRequest("GET", "/alias", "test", "127.0.0.1")
+	if !strings.Contains(buf.String(), "WARNING (GET) /alias") {
+		t.Errorf("expected a WARNING request entry for the alias, got %q", buf.String())
+	}
+}
+
+func TestIncludeRequestGeo(t *testing.T) {
+	buf := captureOutput(t)
+	SetIncludeRequestGeo(true)
+	t.Cleanup(func() {
+		SetIncludeRequestGeo(false)
+	})
+
+	LogRequest(&Request{Method: "GET", Path: "/api", UserAgent: "UA", IP: "1.2.3.4", City: "Berlin", CountryCode: "DE"})
+	if !strings.Contains(buf.String(), "(GET) /api <- UA @ 1.2.3.4 [Berlin, DE]\n") {
+		t.Errorf("expected the geo suffix, got %q", buf.String())
+	}
+
+	// a failed lookup adds nothing
+	buf.Reset()
+	req := &Request{Method: "GET", Path: "/api", UserAgent: "UA", IP: "1.2.3.4"}
+	setUnknownGeo(req)
+	LogRequest(req)
+	if !strings.HasSuffix(buf.String(), "(GET) /api <- UA @ 1.2.3.4\n") {
+		t.Errorf("expected no geo suffix, got %q", buf.String())
+	}
+}
+
+// sampleRequest returns a request with every field set, including values that need quoting in CSV.
+func sampleRequest() *Request {
+	return &Request{
+		ConnectionTime:  "2023-01-01 10:00:00",
+		Method:          "GET",
+		Path:            "/search?q=a,b",
+		IP:              "203.0.113.7",
+		Address:         "203.0.113.7:51234",
+		UserAgent:       `Mozilla/5.0 (X11; Linux x86_64) "quoted"`,
+		Referer:         "https://x/?a=1,b=2",
+		RequestedHost:   "example.com",
+		Continent:       "Europe",
+		Country:         "Germany",
+		CountryCode:     "DE",
+		City:            `Frankfurt "am Main"`,
+		Latitude:        50.125,
+		Longitude:       8.5,
+		Timezone:        "Europe/Berlin",
+		PostalCode:      "60311",
+		Subdivision:     "Hesse",
+		SubdivisionCode: "HE",
+		ConnectionID:    42,
+		ConnectionSeq:   7,
+		Count:           1,
+		ClientLocalTime: "2023-01-01T11:00:00+01:00",
+		Extra:           map[string]string{"route": "search", "tenant": "a,b"},
+		StatusCode:      200,
+		ResponseBytes:   512,
+		Duration:        1500 * time.Microsecond,
+	}
+}
+
+// emptyGeoIPDB returns a City database without any records, so every lookup succeeds without a result, like the
+// one of a private IP in the MaxMind databases.
+func emptyGeoIPDB() []byte {
+	var db []byte
+	// search tree of a single node, both records pointing to the node count mean "no data"
+	db = append(db, 0, 0, 1, 0, 0, 1)
+	// data section separator and empty data section
+	db = append(db, make([]byte, 16)...)
+	// metadata, a map of 4 pairs
+	db = append(db, "\xAB\xCD\xEFMaxMind.com"...)
+	db = append(db, 0xE4)
+	db = append(db, 0x4A)
+	db = append(db, "node_count"...)
+	db = append(db, 0xC1, 1)
+	db = append(db, 0x4B)
+	db = append(db, "record_size"...)
+	db = append(db, 0xA1, 24)
+	db = append(db, 0x4A)
+	db = append(db, "ip_version"...)
+	db = append(db, 0xA1, 6)
+	db = append(db, 0x4D)
+	db = append(db, "database_type"...)
+	db = append(db, 0x4B)
+	db = append(db, "GeoIP2-City"...)
+
+	return db
+}
+
+// useEmptyGeoIPDB sets GeoIPDB to the database of emptyGeoIPDB for the duration of the test.
+func useEmptyGeoIPDB(t *testing.T) {
+	t.Helper()
+
+	reader, err := geoip2.FromBytes(emptyGeoIPDB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	GeoIPDB = reader
+	t.Cleanup(func() {
+		GeoIPDB = nil
+		reader.Close()
+	})
+}
+
+func TestGeoIPLookupWithoutRecord(t *testing.T) {
+	buf, dir := captureOutputAndDir(t)
+	enableRequestLog(t)
+	useEmptyGeoIPDB(t)
+
+	for _, ip := range []string{"127.0.0.1", "::1"} {
+		req := &Request{Method: "GET", Path: "/private", IP: ip}
+		enrich(req)
+		LogRequest(req)
+
+		if req.Country != "Unknown" || req.CountryCode != "" || req.City != "" || req.Latitude != 0 {
+			t.Errorf("expected an unknown location for %s, got %+v", ip, req)
+		}
+	}
+
+	if strings.Contains(buf.String(), "Could not look up") {
+		t.Errorf("expected no warning, got %q", buf.String())
+	}
+	records := readCSV(t, filepath.Join(dir, "requests-"+now().Format("2006-01-02")+".csv"))
+	if len(records) != 3 {
+		t.Fatalf("expected the header and 2 rows, got %d records", len(records))
+	}
+	if records[2][indexOf(GetCSVHeader(), "ip")] != "::1" {
+		t.Errorf("expected the logged request, got %q", records[2])
+	}
+}
+
+func TestGeoIPLookupWithInvalidIP(t *testing.T) {
+	buf := captureOutput(t)
+	useEmptyGeoIPDB(t)
+
+	for _, ip := range []string{"garbage", "", "unknown"} {
+		req := &Request{Method: "GET", Path: "/", IP: ip}
+		enrich(req)
+
+		if req.Country != "Unknown" || req.City != "" || req.Latitude != 0 || req.Longitude != 0 {
+			t.Errorf("expected an unknown location for %q, got %+v", ip, req)
+		}
+	}
+
+	// the lookup is skipped, so it can't fail
+	if buf.Len() != 0 {
+		t.Errorf("expected no warning, got %q", buf.String())
+	}
+}
+
+func TestToCSVQuoting(t *testing.T) {
+	req := sampleRequest()
+	req.Path = "/multi\nline"
+
+	records, err := csv.NewReader(strings.NewReader(req.ToCSV())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected a single row, got %d", len(records))
+	}
+
+	header := GetCSVHeader()
+	row := records[0]
+	if len(row) != len(header) {
+		t.Fatalf("expected %d columns, got %d", len(header), len(row))
+	}
+	expected := map[string]string{
+		"path":       "/multi\nline",
+		"referer":    "https://x/?a=1,b=2",
+		"city":       `Frankfurt "am Main"`,
+		"user_agent": `Mozilla/5.0 (X11; Linux x86_64) "quoted"`,
+		"country":    "Germany",
+	}
+	for column, value := range expected {
+		if actual := row[indexOf(header, column)]; actual != value {
+			t.Errorf("expected %s to be %q, got %q", column, value, actual)
+		}
+	}
+}
+
+func TestRequestCSVFileRoundTrip(t *testing.T) {
+	dir := setupLogDir(t)
+	enableRequestLog(t)
+	SetHideRequestsFromMainLog(true)
+	t.Cleanup(func() {
+		SetHideRequestsFromMainLog(false)
+	})
+
+	LogRequest(sampleRequest())
+
+	records := readCSV(t, filepath.Join(dir, "requests-"+now().Format("2006-01-02")+".csv"))
+	if len(records) != 2 {
+		t.Fatalf("expected the header and a row, got %d records", len(records))
+	}
+	header := GetCSVHeader()
+	if strings.Join(records[0], ",") != strings.Join(header, ",") {
+		t.Errorf("expected the header %q, got %q", header, records[0])
+	}
+
+	expected := []string{
+		"2023-01-01 10:00:00",
+		"GET",
+		"/search?q=a,b",
+		"203.0.113.7",
+		"203.0.113.7:51234",
+		`Mozilla/5.0 (X11; Linux x86_64) "quoted"`,
+		"https://x/?a=1,b=2",
+		"example.com",
+		"Europe",
+		"Germany",
+		"DE",
+		`Frankfurt "am Main"`,
+		"50.125000000000",
+		"8.500000000000",
+		"Europe/Berlin",
+		"60311",
+		"Hesse",
+		"HE",
+		"42",
+		"7",
+		"1",
+		"2023-01-01T11:00:00+01:00",
+		`{"route":"search","tenant":"a,b"}`,
+		"200",
+		"512",
+		"1.5ms",
+	}
+	row := records[1]
+	if len(row) != len(expected) {
+		t.Fatalf("expected %d columns, got %d", len(expected), len(row))
+	}
+	for i, value := range expected {
+		if row[i] != value {
+			t.Errorf("expected %s to be %q, got %q", header[i], value, row[i])
+		}
+	}
+}
+
+func TestRequestExtra(t *testing.T) {
+	tests := []struct {
+		extra    map[string]string
+		expected string
+	}{
+		{nil, ""},
+		{map[string]string{}, "{}"},
+		{map[string]string{"tenant": "a", "route": "search", "b": `"quoted"`}, `{"b":"\"quoted\"","route":"search","tenant":"a"}`},
+	}
+
+	column := indexOf(GetCSVHeader(), "extra")
+	for _, test := range tests {
+		req := &Request{Method: "GET", Path: "/", Extra: test.extra}
+		records, err := csv.NewReader(strings.NewReader(req.ToCSV())).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := records[0][column]; actual != test.expected {
+			t.Errorf("expected the extra column %q for %v, got %q", test.expected, test.extra, actual)
+		}
+	}
+}
+
+func TestLogRequestFromHTTP(t *testing.T) {
+	buf := captureOutput(t)
+
+	r := httptest.NewRequest("GET", "http://example.com/items?id=1", nil)
+	r.Header.Set("X-Forwarded-For", " 198.51.100.1 , 10.0.0.1")
+	r.Header.Set("User-Agent", "test-agent")
+	r.Header.Set("Referer", "https://example.org/")
+
+	req := requestFromHTTP(r)
+	expected := map[string]string{
+		"method":         "GET",
+		"path":           "/items",
+		"ip":             "198.51.100.1",
+		"address":        "192.0.2.1:1234",
+		"user_agent":     "test-agent",
+		"referer":        "https://example.org/",
+		"requested_host": "example.com",
+	}
+	record := req.csvRecord()
+	for column, value := range expected {
+		if actual := record[indexOf(GetCSVHeader(), column)]; actual != value {
+			t.Errorf("expected %s to be %q, got %q", column, value, actual)
+		}
+	}
+
+	// without the header, the remote address is used
+	r.Header.Del("X-Forwarded-For")
+	LogRequestFromHTTP(r)
+	if !strings.HasSuffix(buf.String(), "INFO (GET) /items <- test-agent @ 192.0.2.1\n") {
+		t.Errorf("expected the request entry, got %q", buf.String())
+	}
+}
+
+func TestRequestResponseColumns(t *testing.T) {
+	header := GetCSVHeader()
+	last := strings.Join(header[len(header)-3:], ",")
+	if last != "status_code,response_bytes,duration" {
+		t.Errorf("expected the response columns at the end, got %s", last)
+	}
+
+	tests := []struct {
+		req      *Request
+		expected []string
+	}{
+		{&Request{StatusCode: 503, ResponseBytes: 1 << 20, Duration: 2*time.Second + 250*time.Millisecond}, []string{"503", "1048576", "2.25s"}},
+		{&Request{}, []string{"0", "0", "0s"}},
+	}
+	for _, test := range tests {
+		record := test.req.csvRecord()
+		actual := record[len(record)-3:]
+		if strings.Join(actual, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("expected the columns %q, got %q", test.expected, actual)
+		}
+	}
+
+	b, err := json.Marshal(tests[0].req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range []string{`"status_code":503`, `"response_bytes":1048576`, `"duration":2250000000`} {
+		if !strings.Contains(string(b), pair) {
+			t.Errorf("expected %s in %s", pair, b)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxLogAgeDays is the number of days log files are kept by PruneOldLogs. 0 keeps them forever. Default: 0
+// Assigning it is only safe before the first entry is logged, use SetMaxLogAgeDays afterwards.
+var MaxLogAgeDays = 0
+
+// AutoPruneOldLogs sets whether PruneOldLogs runs automatically in the background with the first entry of
+// every day, including the first entry after the application started. Default: false
+// Assigning it is only safe before the first entry is logged, use SetAutoPruneOldLogs afterwards.
+var AutoPruneOldLogs = false
+
+// SetMaxLogAgeDays sets the number of days log files are kept by PruneOldLogs, see MaxLogAgeDays.
+func SetMaxLogAgeDays(days int) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	MaxLogAgeDays = days
+}
+
+// SetAutoPruneOldLogs sets whether PruneOldLogs runs automatically, see AutoPruneOldLogs.
+func SetAutoPruneOldLogs(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	AutoPruneOldLogs = enabled
+}
+
+// mainLogNamePattern matches the names of the dated main log files, e.g. 2024-01-31.log or 2024-01-31.log.gz
+var mainLogNamePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\.log(\.gz)?$`)
+
+var pruneMu sync.Mutex
+var lastPruneDate = ""
+
+// PruneOldLogs deletes the main log files (YYYY-MM-DD.log), error log files (errors-YYYY-MM-DD.log), files of the
+// components enabled by SetComponentFile (component-YYYY-MM-DD.log) and request log files (requests-YYYY-MM-DD.csv,
+// including their segments and the simple request logs) whose date is more than MaxLogAgeDays days ago.
+// The date is taken from the filename; files that don't match these patterns are left untouched.
+// It does nothing if MaxLogAgeDays is 0.
+func PruneOldLogs() error {
+	s := currentSettings()
+	if s.maxLogAgeDays <= 0 {
+		return nil
+	}
+
+	reconfigureMu.RLock()
+	logDir := LogDir
+	reconfigureMu.RUnlock()
+
+	// entries of the oldest kept day are still kept
+	today := now()
+	oldest := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location()).AddDate(0, 0, -s.maxLogAgeDays)
+
+	requestNamePattern := regexp.MustCompile(`^` + regexp.QuoteMeta(s.requestLogPrefix) + `-(?:simple-)?(\d{4}-\d{2}-\d{2})(?:\.\d+)?\.csv(\.gz)?$`)
+	dailyNamePattern := dailyLogNamePattern()
+
+	dirs := []string{logDir}
+	if s.requestLogDir != "" && filepath.Clean(s.requestLogDir) != filepath.Clean(logDir) {
+		dirs = append(dirs, s.requestLogDir)
+	}
+
+	removed := 0
+	var firstErr error
+	for _, dir := range dirs {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+
+			match := mainLogNamePattern.FindStringSubmatch(file.Name())
+			if match == nil {
+				match = requestNamePattern.FindStringSubmatch(file.Name())
+			}
+			if match == nil {
+				match = dailyNamePattern.FindStringSubmatch(file.Name())
+			}
+			if match == nil {
+				continue
+			}
+
+			date, err := time.ParseInLocation("2006-01-02", match[1], today.Location())
+			if err != nil || !date.Before(oldest) {
+				continue
+			}
+
+			err = os.Remove(filepath.Join(dir, file.Name()))
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		logLifecycleEvent(EventRetentionDeleted, map[string]interface{}{"files": removed})
+	}
+
+	return firstErr
+}
+
+// dailyLogNamePattern returns the pattern of the names of the dated error log files and the files of the
+// components enabled by SetComponentFile, e.g. errors-2024-01-31.log or billing-2024-01-31.log
+func dailyLogNamePattern() *regexp.Regexp {
+	names := []string{regexp.QuoteMeta("errors")}
+
+	componentFilesMu.RLock()
+	for component := range componentFiles {
+		names = append(names, regexp.QuoteMeta(componentFileName(component)))
+	}
+	componentFilesMu.RUnlock()
+
+	return regexp.MustCompile(`^(?:` + strings.Join(names, "|") + `)-(\d{4}-\d{2}-\d{2})\.log(\.gz)?$`)
+}
+
+// autoPruneOldLogs runs PruneOldLogs in the background with the first entry of the given day,
+// if AutoPruneOldLogs is enabled in s.
+func autoPruneOldLogs(s settings, date string) {
+	if !s.autoPruneOldLogs || s.maxLogAgeDays <= 0 {
+		return
+	}
+
+	pruneMu.Lock()
+	if lastPruneDate == date {
+		pruneMu.Unlock()
+		return
+	}
+	lastPruneDate = date
+	pruneMu.Unlock()
+
+	go func() {
+		err := PruneOldLogs()
+		if err != nil {
+			reportError(fmt.Errorf("could not prune old logs: %w", err))
+		}
+	}()
+}
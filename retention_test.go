@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createBackdated creates the file in dir with a modification time of the given number of days ago.
+func createBackdated(t *testing.T, dir string, name string, days int) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte("entry\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtime := now().AddDate(0, 0, -days)
+	err = os.Chtimes(path, mtime, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneOldLogs(t *testing.T) {
+	dir := setupLogDir(t)
+	SetMaxLogAgeDays(7)
+	SetComponentFile("billing/eu", true)
+	t.Cleanup(func() {
+		SetMaxLogAgeDays(0)
+		SetComponentFile("billing/eu", false)
+	})
+
+	day := func(days int) string {
+		return now().AddDate(0, 0, -days).Format("2006-01-02")
+	}
+	stale := []string{
+		day(8) + ".log",
+		day(30) + ".log.gz",
+		"requests-" + day(8) + ".csv",
+		"requests-" + day(9) + ".1.csv",
+		"requests-simple-" + day(10) + ".csv",
+		"errors-" + day(8) + ".log",
+		"billing_eu-" + day(8) + ".log",
+	}
+	kept := []string{
+		day(0) + ".log",
+		day(7) + ".log",
+		"requests-" + day(7) + ".csv",
+		"notes.txt",
+		"backup-" + day(30) + ".log",
+		day(30) + ".log.bak",
+		"errors-" + day(7) + ".log",
+		"billing_eu-" + day(7) + ".log",
+		"mailer-" + day(30) + ".log",
+	}
+	for _, name := range stale {
+		createBackdated(t, dir, name, 30)
+	}
+	for _, name := range kept {
+		createBackdated(t, dir, name, 30)
+	}
+
+	err := PruneOldLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range stale {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", name, err)
+		}
+	}
+	for _, name := range kept {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be kept, got %v", name, err)
+		}
+	}
+}
+
+func TestPruneOldLogsDisabled(t *testing.T) {
+	dir := setupLogDir(t)
+	name := now().AddDate(0, 0, -365).Format("2006-01-02") + ".log"
+	createBackdated(t, dir, name, 365)
+
+	err := PruneOldLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+		t.Errorf("expected the file to be kept without MaxLogAgeDays, got %v", err)
+	}
+}
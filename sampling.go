@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+)
+
+// keySampler keeps every n-th message matching its key.
+type keySampler struct {
+	n     uint64
+	count uint64
+}
+
+var keySamplingMu sync.Mutex
+var keySamplers = map[string]*keySampler{}
+
+// levelSamplers and sampledByLevel are guarded by keySamplingMu as well
+var levelSamplers = map[string]*keySampler{}
+var sampledByLevel = map[string]uint64{}
+
+// SetSamplingForKey keeps only every n-th message that starts with the given key, dropping the others.
+// The key is matched against the beginning of the content, so it matches the exact message as well as
+// messages with a variable suffix. If multiple keys match, the longest one is used.
+// Messages not matching any key are not affected. An n of 1 or less removes the sampling for the key.
+func SetSamplingForKey(key string, n int) {
+	keySamplingMu.Lock()
+	defer keySamplingMu.Unlock()
+
+	if n <= 1 {
+		delete(keySamplers, key)
+		return
+	}
+
+	keySamplers[key] = &keySampler{n: uint64(n)}
+}
+
+// SetSampleRate keeps only every n-th message of the given level, dropping the others. This keeps a hot code
+// path logging e.g. at INFO from overwhelming the disk. Levels are not sampled by default, and FATAL messages are
+// never dropped. An n of 1 or less removes the sampling for the level. Messages kept by the level sampling may
+// still be dropped by the sampling of SetSamplingForKey.
+// The number of dropped messages per level is reported by GetMetrics.
+func SetSampleRate(level string, n int) {
+	level, _ = resolveLevel(level)
+
+	keySamplingMu.Lock()
+	defer keySamplingMu.Unlock()
+
+	if n <= 1 {
+		delete(levelSamplers, level)
+		return
+	}
+
+	levelSamplers[level] = &keySampler{n: uint64(n)}
+}
+
+// sampledOut reports whether the message should be dropped because of the level or key sampling.
+func sampledOut(level string, content string) bool {
+	keySamplingMu.Lock()
+	defer keySamplingMu.Unlock()
+
+	if sampler, ok := levelSamplers[level]; ok && sampler.drop() {
+		sampledByLevel[level]++
+		sampledEntries.Add(1)
+		return true
+	}
+
+	if len(keySamplers) == 0 {
+		return false
+	}
+
+	var sampler *keySampler
+	matched := -1
+	for key, s := range keySamplers {
+		if len(key) > matched && strings.HasPrefix(content, key) {
+			sampler = s
+			matched = len(key)
+		}
+	}
+
+	if sampler == nil || !sampler.drop() {
+		return false
+	}
+
+	sampledByLevel[level]++
+	sampledEntries.Add(1)
+	return true
+}
+
+// drop counts the message and reports whether it's dropped, i.e. whether it's not the n-th one.
+// keySamplingMu must be held.
+func (s *keySampler) drop() bool {
+	s.count++
+	return (s.count-1)%s.n != 0
+}
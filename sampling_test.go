@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// countContaining returns the number of lines containing the given text.
+func countContaining(lines []string, text string) int {
+	n := 0
+	for _, line := range lines {
+		if strings.Contains(line, text) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestSamplingForKey(t *testing.T) {
+	buf := captureOutput(t)
+
+	SetSamplingForKey("cache miss", 5)
+	t.Cleanup(func() {
+		SetSamplingForKey("cache miss", 0)
+	})
+
+	for i := 0; i < 20; i++ {
+		Info("cache miss for key")
+		Info("request handled")
+	}
+
+	lines := nonEmptyLines(buf.String())
+	if n := countContaining(lines, "cache miss"); n != 4 {
+		t.Errorf("expected every 5th sampled message, got %d of 20", n)
+	}
+	if n := countContaining(lines, "request handled"); n != 20 {
+		t.Errorf("expected all messages of the other key, got %d of 20", n)
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	buf := captureOutput(t)
+	before := GetMetrics().DroppedSampledByLevel[LevelInfo]
+
+	SetSampleRate(LevelInfo, 10)
+	t.Cleanup(func() {
+		SetSampleRate(LevelInfo, 0)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				Info("hot path")
+			}
+		}()
+	}
+	wg.Wait()
+	Warning("not sampled")
+
+	lines := nonEmptyLines(buf.String())
+	if n := countContaining(lines, "INFO hot path"); n != 10 {
+		t.Errorf("expected 10 of 100 INFO entries, got %d", n)
+	}
+	if countContaining(lines, "WARNING not sampled") != 1 {
+		t.Error("expected the WARNING entry not to be sampled")
+	}
+	if dropped := GetMetrics().DroppedSampledByLevel[LevelInfo] - before; dropped != 90 {
+		t.Errorf("expected 90 dropped INFO entries, got %d", dropped)
+	}
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// shutdownPollInterval is the interval in which Shutdown checks whether all asynchronous entries were written.
+const shutdownPollInterval = 10 * time.Millisecond
+
+// Shutdown flushes and closes everything the logger holds open. It should be called before the application exits.
+// It waits until the asynchronous entries are written or ctx is done, writes the requests held back by the
+// deduplication and the summaries of suppressed repetitions and logs a NOTICE entry summarizing the run: the
+// entries written per level, the bytes written, the dropped entries and the uptime. The summary is logged even if
+// the minimum log level is above NOTICE. Afterwards, the entries
+// queued for the remote endpoint and CloudWatch are sent, the connection to syslog is closed, the writers set by
+// SetOutput and AddSink are flushed, the log files are closed, the last rotated main log file is compressed if
+// enabled by CompressRotatedLogs and the lock of the log directory is released.
+// The asynchronous entries include the ones in the queue started by StartAsync, which is drained.
+// If ctx is done before the asynchronous entries were written, the remaining steps are still performed and
+// ctx.Err() is returned.
+func Shutdown(ctx context.Context) error {
+	// wait for the asynchronous entries
+	var ctxErr error
+	for asyncPending.Load() > 0 && ctxErr == nil {
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+		case <-time.After(shutdownPollInterval):
+		}
+	}
+
+	FlushRequestDedup()
+	flushRepeats()
+
+	err := logShutdownSummary()
+
+	// send the entries queued for the remote endpoint
+	Flush()
+
+	// send the pending entries and stop the sink
+	SetCloudWatch("", "")
+
+	syslogErr := closeSyslog()
+	if err == nil {
+		err = syslogErr
+	}
+
+	outputsErr := flushOutputs()
+	if err == nil {
+		err = outputsErr
+	}
+
+	closeErr := CloseLogFile()
+	if err == nil {
+		err = closeErr
+	}
+
+	compressErr := compressPendingLog()
+	if err == nil {
+		err = compressErr
+	}
+
+	lockErr := ReleaseDirLock()
+	if err == nil {
+		err = lockErr
+	}
+
+	if ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
+}
+
+// logShutdownSummary logs a NOTICE entry with the counters of the logger, regardless of the minimum log level.
+func logShutdownSummary() error {
+	m := GetMetrics()
+
+	fields := map[string]interface{}{
+		"bytes_written":   m.BytesWritten,
+		"dropped_timeout": m.DroppedTimeout,
+		"dropped_sampled": m.DroppedSampled,
+		"dropped_budget":  m.DroppedBudget,
+		"dropped_async":   m.DroppedAsync,
+		"uptime_seconds":  fmt.Sprintf("%.3f", time.Since(startedAt).Seconds()),
+	}
+	for level, n := range m.WrittenByLevel {
+		fields["written_"+strings.ToLower(level)] = n
+	}
+
+	// a logger with its own minimum level isn't affected by the global one, so the run always ends with the summary
+	summary := &Logger{level: LevelNotice}
+	return summary.write(LevelNotice, "logger shutdown", fields)
+}
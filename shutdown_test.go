@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestShutdownSummary(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+	before := GetMetrics()
+
+	Debug("debug")
+	Info("first")
+	Info("second")
+	Warning("warning")
+	Error("error")
+	Error("error")
+	Error("error")
+
+	err := Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 8 {
+		t.Fatalf("expected 7 entries and the summary, got %d", len(entries))
+	}
+	summary := entries[7]
+	if summary["level"] != LevelNotice || summary["msg"] != "logger shutdown" {
+		t.Fatalf("expected the summary last, got %v", summary)
+	}
+
+	expected := map[string]int{LevelDebug: 1, LevelInfo: 2, LevelWarning: 1, LevelError: 3}
+	for level, n := range expected {
+		key := "written_" + strings.ToLower(level)
+		if summary[key] != float64(before.WrittenByLevel[level]+uint64(n)) {
+			t.Errorf("expected %s to be %d more than before, got %v", key, n, summary[key])
+		}
+	}
+
+	bytes, ok := summary["bytes_written"].(float64)
+	if !ok || uint64(bytes) <= before.BytesWritten {
+		t.Errorf("expected the written bytes to increase, got %v", summary["bytes_written"])
+	}
+	for _, key := range []string{"dropped_timeout", "dropped_sampled", "dropped_budget", "dropped_async", "uptime_seconds"} {
+		if _, ok := summary[key]; !ok {
+			t.Errorf("expected %s in the summary", key)
+		}
+	}
+}
+
+func TestShutdownSummaryAboveMinimumLevel(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+	SetMinimumLogLevel(LevelError)
+
+	Info("suppressed")
+
+	err := Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 1 || entries[0]["msg"] != "logger shutdown" {
+		t.Fatalf("expected only the summary, got %v", entries)
+	}
+}
@@ -0,0 +1,123 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slog levels of the levels that slog doesn't define, for use with slog.Logger.Log
+const (
+	SlogLevelNotice    = slog.Level(2)
+	SlogLevelEmergency = slog.Level(12)
+	SlogLevelFatal     = slog.Level(16)
+)
+
+// slogHandler is the slog.Handler returned by NewSlogHandler.
+type slogHandler struct {
+	// fields are the attributes added by WithAttrs, with the keys already qualified by their groups
+	fields map[string]interface{}
+
+	// prefix is the qualifier of the groups opened by WithGroup, e.g. "request.headers."
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler that writes the records to the main log like LogFields.
+// The slog levels are mapped to the levels of this package: Debug to DEBUG, Info to INFO, Warn to WARNING and
+// Error to ERROR, while SlogLevelNotice, SlogLevelEmergency and SlogLevelFatal map to NOTICE, EMERGENCY and
+// FATAL. Levels in between are mapped to the next lower level.
+// The attributes of the record become fields of the entry; attributes of groups are prefixed with the names of
+// the groups, separated by dots, e.g. "request.method".
+func NewSlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+// Enabled reports whether entries of the given level are written, which depends on the minimum log level.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return std.minimumWeight() <= LevelWeights[levelFromSlog(level)]
+}
+
+// Handle writes the record to the main log.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(map[string]interface{}, len(h.fields)+r.NumAttrs())
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(fields, h.prefix, a)
+		return true
+	})
+
+	std.dispatchFields(levelFromSlog(r.Level), r.Message, fields)
+	return nil
+}
+
+// WithAttrs returns a handler that adds the given attributes to every record.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(map[string]interface{}, len(h.fields)+len(attrs))
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+
+	// qualify the keys now, as groups opened later don't apply to these attributes
+	for _, a := range attrs {
+		addSlogAttr(fields, h.prefix, a)
+	}
+
+	return &slogHandler{fields: fields, prefix: h.prefix}
+}
+
+// WithGroup returns a handler that qualifies the keys of the following attributes with the given group.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &slogHandler{
+		fields: h.fields,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+// addSlogAttr adds the attribute to the fields with its key qualified by the given prefix. Groups are flattened
+// and empty attributes are ignored, as described by slog.Handler.
+func addSlogAttr(fields map[string]interface{}, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		// attributes of groups without a key belong to the enclosing group
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addSlogAttr(fields, prefix, ga)
+		}
+		return
+	}
+
+	fields[prefix+a.Key] = a.Value.Any()
+}
+
+// levelFromSlog returns the level of this package for the given slog level.
+func levelFromSlog(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < SlogLevelNotice:
+		return LevelInfo
+	case level < slog.LevelWarn:
+		return LevelNotice
+	case level < slog.LevelError:
+		return LevelWarning
+	case level < SlogLevelEmergency:
+		return LevelError
+	case level < SlogLevelFatal:
+		return LevelEmergency
+	default:
+		return LevelFatal
+	}
+}
@@ -0,0 +1,59 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	dir := setupLogDir(t)
+	SetMinimumLogLevel(LevelInfo)
+
+	logger := slog.New(NewSlogHandler()).With("service", "api")
+	logger.Debug("below the minimum level")
+	logger.WithGroup("req").Info("handled", "method", "GET", slog.Int("status", 200))
+	logger.Warn("slow", slog.Group("timing", slog.Duration("total", 1500*time.Millisecond)))
+	logger.Log(context.Background(), SlogLevelNotice, "notice")
+
+	lines := readMainLog(t, dir)
+	expected := []string{
+		"INFO handled req.method=GET req.status=200 service=api",
+		"WARNING slow service=api timing.total=1.5s",
+		"NOTICE notice service=api",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d entries, got %q", len(expected), lines)
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, expected[i]) {
+			t.Errorf("expected %q, got %q", expected[i], line)
+		}
+	}
+}
+
+func TestLevelFromSlog(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		expected string
+	}{
+		{slog.LevelDebug, LevelDebug},
+		{slog.LevelInfo, LevelInfo},
+		{slog.LevelInfo + 1, LevelInfo},
+		{SlogLevelNotice, LevelNotice},
+		{slog.LevelWarn, LevelWarning},
+		{slog.LevelError, LevelError},
+		{SlogLevelEmergency, LevelEmergency},
+		{SlogLevelFatal, LevelFatal},
+	}
+
+	for _, test := range tests {
+		if actual := levelFromSlog(test.level); actual != test.expected {
+			t.Errorf("levelFromSlog(%v) = %s, expected %s", test.level, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// span is a started unit of work that has not ended yet.
+type span struct {
+	name  string
+	start time.Time
+}
+
+var spansMu sync.Mutex
+var spans = map[string]span{}
+
+// LogSpanStart logs the start of a span with the given name and returns its ID.
+// Pass the ID to LogSpanEnd once the work is done; both entries carry the same span_id so they can be
+// correlated, and the end entry includes the duration in between. Spans that are never ended are kept
+// in memory, so every LogSpanStart must be paired with a LogSpanEnd.
+func LogSpanStart(name string) string {
+	id := newSpanID()
+
+	spansMu.Lock()
+	spans[id] = span{name: name, start: time.Now()}
+	spansMu.Unlock()
+
+	Log(LevelInfo, fmt.Sprintf("span started: %s span_id=%s", name, id))
+
+	return id
+}
+
+// LogSpanEnd logs the end of the span with the given ID, including the duration since LogSpanStart.
+// If no span with this ID was started (or it already ended), a warning is logged instead.
+func LogSpanEnd(spanID string) {
+	spansMu.Lock()
+	s, ok := spans[spanID]
+	delete(spans, spanID)
+	spansMu.Unlock()
+
+	if !ok {
+		Warning("span ended without being started: span_id=" + spanID)
+		return
+	}
+
+	Log(LevelInfo, fmt.Sprintf("span ended: %s span_id=%s duration=%s", s.name, spanID, time.Since(s.start)))
+}
+
+// newSpanID returns a random 16 character hex ID.
+func newSpanID() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		// fall back to the current time, which is still unique enough for correlating entries
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpan(t *testing.T) {
+	buf := captureOutput(t)
+
+	id := LogSpanStart("import")
+	time.Sleep(10 * time.Millisecond)
+	LogSpanEnd(id)
+
+	lines := nonEmptyLines(buf.String())
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got %q", lines)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "span_id="+id) {
+			t.Errorf("expected span_id=%s in %q", id, line)
+		}
+	}
+
+	_, durationText, ok := strings.Cut(lines[1], "duration=")
+	if !ok {
+		t.Fatalf("expected a duration in %q", lines[1])
+	}
+	duration, err := time.ParseDuration(durationText)
+	if err != nil || duration < 10*time.Millisecond {
+		t.Errorf("expected a duration of at least 10ms, got %q", durationText)
+	}
+}
+
+func TestSpanEndWithoutStart(t *testing.T) {
+	buf := captureOutput(t)
+
+	LogSpanEnd("unknown")
+
+	if !strings.Contains(buf.String(), "WARNING span ended without being started: span_id=unknown") {
+		t.Errorf("expected a warning, got %q", buf.String())
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows || plan9
+
+package logger
+
+import "errors"
+
+// SetSyslog is not supported on this system and always returns an error.
+func SetSyslog(network string, addr string, tag string) error {
+	return errors.New("syslog is not supported on this system")
+}
+
+// writeSyslog is a no-op on systems without syslog.
+func writeSyslog(e logEntry) {}
+
+// closeSyslog is a no-op on systems without syslog.
+func closeSyslog() error {
+	return nil
+}
@@ -0,0 +1,89 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+	"sync"
+)
+
+var syslogMu sync.Mutex
+var syslogWriter *syslog.Writer
+
+// SetSyslog additionally sends all entries of the main log to the syslog server at the given address, tagged with
+// the given tag. The network is "tcp", "udp" or "unixgram"; an empty network and address connect to the local
+// syslog server. The severity is mapped from the level: DEBUG to LOG_DEBUG, INFO to LOG_INFO, NOTICE to
+// LOG_NOTICE, WARNING to LOG_WARNING, ERROR to LOG_ERR, EMERGENCY to LOG_EMERG and FATAL to LOG_CRIT.
+// Entries below the minimum log level are not sent. Calling it again replaces the previous connection.
+// The messages are framed by log/syslog in the BSD format of RFC 3164, not RFC 5424, so servers expecting
+// structured data must parse the message itself. They always contain the entry in the text format, whatever the
+// output format of the main log is, as syslog can't carry the binary protobuf entries.
+func SetSyslog(network string, addr string, tag string) error {
+	w, err := syslog.Dial(network, addr, syslog.LOG_USER|syslog.LOG_INFO, tag)
+	if err != nil {
+		return err
+	}
+
+	syslogMu.Lock()
+	previous := syslogWriter
+	syslogWriter = w
+	syslogMu.Unlock()
+
+	if previous != nil {
+		_ = previous.Close()
+	}
+
+	return nil
+}
+
+// writeSyslog sends the entry in the text format to the syslog server with the severity of its level, if one is
+// set.
+func writeSyslog(e logEntry) {
+	syslogMu.Lock()
+	defer syslogMu.Unlock()
+
+	if syslogWriter == nil {
+		return
+	}
+
+	msg := strings.TrimSuffix(formatText(e), "\n")
+	level := e.level
+
+	var err error
+	switch level {
+	case LevelDebug:
+		err = syslogWriter.Debug(msg)
+	case LevelInfo:
+		err = syslogWriter.Info(msg)
+	case LevelNotice:
+		err = syslogWriter.Notice(msg)
+	case LevelWarning:
+		err = syslogWriter.Warning(msg)
+	case LevelError:
+		err = syslogWriter.Err(msg)
+	case LevelEmergency:
+		err = syslogWriter.Emerg(msg)
+	case LevelFatal:
+		err = syslogWriter.Crit(msg)
+	}
+
+	if err != nil {
+		reportError(fmt.Errorf("could not write to syslog: %w", err))
+	}
+}
+
+// closeSyslog closes the connection to the syslog server, if any.
+func closeSyslog() error {
+	syslogMu.Lock()
+	defer syslogMu.Unlock()
+
+	if syslogWriter == nil {
+		return nil
+	}
+
+	err := syslogWriter.Close()
+	syslogWriter = nil
+	return err
+}
@@ -0,0 +1,60 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslog(t *testing.T) {
+	setupLogDir(t)
+	SetMinimumLogLevel(LevelInfo)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = SetSyslog("udp", conn.LocalAddr().String(), "logger-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		closeSyslog()
+	})
+
+	Debug("below the minimum level")
+	Warning("disk almost full")
+	Error("disk full")
+	Log(LevelEmergency, "out of disk")
+
+	// LOG_USER is facility 1, so the priority is 8 plus the severity
+	expected := []struct {
+		priority string
+		message  string
+	}{
+		{"<12>", "WARNING disk almost full"},
+		{"<11>", "ERROR disk full"},
+		{"<8>", "EMERGENCY out of disk"},
+	}
+	buf := make([]byte, 4096)
+	for _, e := range expected {
+		err = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("expected a syslog message for %q, got %v", e.message, err)
+		}
+
+		msg := strings.TrimSpace(string(buf[:n]))
+		if !strings.HasPrefix(msg, e.priority) || !strings.Contains(msg, " logger-test[") || !strings.HasSuffix(msg, e.message) {
+			t.Errorf("expected %s and %q in the message, got %q", e.priority, e.message, msg)
+		}
+	}
+}
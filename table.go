@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tableMaxColumnWidth is the maximum width of a column, longer cells are truncated.
+const tableMaxColumnWidth = 40
+
+// tableMaxBytes is the maximum size of a logged table, further rows are omitted.
+const tableMaxBytes = 64 * 1024
+
+// LogTable logs tabular data as a single entry with the given level. The rows are rendered as an aligned text
+// table below the headers, one row per line. Cells wider than 40 characters are truncated and rows beyond 64 KB
+// are omitted, noting how many were left out. Rows with fewer cells than headers are padded with empty cells,
+// additional cells are ignored.
+// In the JSON format, the entry has the message "table" and the rows as an array of objects in the field rows,
+// with the headers as keys. The number of omitted rows is in the field omitted_rows.
+func LogTable(level string, headers []string, rows [][]string) {
+	if formatForLevel(level) == FormatJSON {
+		objects, omitted := tableObjects(headers, rows)
+
+		fields := map[string]interface{}{"rows": objects}
+		if omitted > 0 {
+			fields["omitted_rows"] = omitted
+		}
+		LogFields(level, "table", fields)
+		return
+	}
+
+	Log(level, formatTable(headers, rows))
+}
+
+// tableObjects converts the rows to objects with the headers as keys, truncated like the text table.
+// It returns the number of omitted rows as well.
+func tableObjects(headers []string, rows [][]string) ([]map[string]string, int) {
+	objects := make([]map[string]string, 0, len(rows))
+
+	size := 0
+	for i, row := range rows {
+		if size >= tableMaxBytes {
+			return objects, len(rows) - i
+		}
+
+		object := make(map[string]string, len(headers))
+		for i, header := range headers {
+			cell := ""
+			if i < len(row) {
+				cell = truncateTableCell(row[i])
+			}
+			object[header] = cell
+			size += len(header) + len(cell)
+		}
+		objects = append(objects, object)
+	}
+
+	return objects, 0
+}
+
+// formatTable renders the headers and rows as an aligned text table.
+func formatTable(headers []string, rows [][]string) string {
+	// get the width of every column
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = tableCellWidth(header)
+	}
+	for _, row := range rows {
+		for i := range headers {
+			if i < len(row) && tableCellWidth(row[i]) > widths[i] {
+				widths[i] = tableCellWidth(row[i])
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("table:\n")
+	writeTableRow(&b, headers, widths)
+
+	separators := make([]string, len(headers))
+	for i, width := range widths {
+		separators[i] = strings.Repeat("-", width)
+	}
+	writeTableRow(&b, separators, widths)
+
+	for i, row := range rows {
+		if b.Len() >= tableMaxBytes {
+			fmt.Fprintf(&b, "... %d more rows omitted\n", len(rows)-i)
+			break
+		}
+		writeTableRow(&b, row, widths)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeTableRow writes the cells padded to the column widths, separated by two spaces.
+func writeTableRow(b *strings.Builder, cells []string, widths []int) {
+	line := make([]string, len(widths))
+	for i, width := range widths {
+		cell := ""
+		if i < len(cells) {
+			cell = truncateTableCell(cells[i])
+		}
+		line[i] = cell + strings.Repeat(" ", width-utf8.RuneCountInString(cell))
+	}
+
+	b.WriteString(strings.TrimRight(strings.Join(line, "  "), " "))
+	b.WriteString("\n")
+}
+
+// tableCellWidth returns the width of the cell after truncating it.
+func tableCellWidth(cell string) int {
+	return utf8.RuneCountInString(truncateTableCell(cell))
+}
+
+// truncateTableCell shortens the cell to the maximum column width and replaces line breaks,
+// which would break the alignment.
+func truncateTableCell(cell string) string {
+	cell = strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(cell)
+	if utf8.RuneCountInString(cell) <= tableMaxColumnWidth {
+		return cell
+	}
+
+	runes := []rune(cell)
+	return string(runes[:tableMaxColumnWidth-3]) + "..."
+}
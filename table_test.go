@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var tableHeaders = []string{"name", "count"}
+var tableRows = [][]string{{"apples", "3"}, {"kiwis", "12"}, {"ümlauts", "1"}}
+
+func TestLogTableText(t *testing.T) {
+	buf := captureOutput(t)
+
+	LogTable(LevelInfo, tableHeaders, tableRows)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	expected := []string{
+		"name     count",
+		"-------  -----",
+		"apples   3",
+		"kiwis    12",
+		"ümlauts  1",
+	}
+	if len(lines) != len(expected)+1 || !strings.HasSuffix(lines[0], "INFO table:") {
+		t.Fatalf("expected the table below the entry, got %q", lines)
+	}
+	if !reflect.DeepEqual(lines[1:], expected) {
+		t.Errorf("expected aligned columns %q, got %q", expected, lines[1:])
+	}
+}
+
+func TestLogTableJSON(t *testing.T) {
+	buf := captureOutput(t)
+	useOutputFormat(t, FormatJSON)
+
+	LogTable(LevelInfo, tableHeaders, tableRows)
+
+	entries := parseJSONLines(t, buf.String())
+	if len(entries) != 1 || entries[0]["msg"] != "table" {
+		t.Fatalf("expected a single table entry, got %v", entries)
+	}
+
+	rows, ok := entries[0]["rows"].([]interface{})
+	if !ok || len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %v", entries[0]["rows"])
+	}
+	expected := map[string]interface{}{"name": "kiwis", "count": "12"}
+	if !reflect.DeepEqual(rows[1], expected) {
+		t.Errorf("expected the row %v, got %v", expected, rows[1])
+	}
+}
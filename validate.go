@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// RequireConfig lists the settings that must be configured explicitly when strict startup is enabled.
+type RequireConfig struct {
+	// MinimumLogLevel requires the minimum log level to be set via LOGGER_MINIMUM_LOG_LEVEL or SetMinimumLogLevel.
+	MinimumLogLevel bool
+
+	// GeoIPDB requires a GeoIP database to be loaded via LOGGER_GEOIP_DB or LoadGeoIPDB.
+	GeoIPDB bool
+}
+
+var strictStartup = false
+var requiredConfig = RequireConfig{MinimumLogLevel: true}
+var minimumLogLevelConfigured = false
+
+var geoIPPath = ""
+var geoIPErr error
+
+// SetStrictStartup sets whether an incomplete or invalid configuration is reported at startup.
+// With strict startup enabled, the settings listed by SetRequiredConfig must be configured and Validate must
+// not return an error. When enabled via LOGGER_STRICT_STARTUP, this is checked at the end of init and the
+// result is returned by StartupError; Configure returns it as well.
+func SetStrictStartup(strict bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	strictStartup = strict
+}
+
+// SetRequiredConfig sets the settings that must be configured when strict startup is enabled.
+// Default: only the minimum log level is required.
+func SetRequiredConfig(required RequireConfig) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	requiredConfig = required
+}
+
+// LoadGeoIPDB opens the GeoIP database at the given path and uses it to enrich logged requests.
+// If the database can't be opened, the error is returned and also reported by Validate.
+func LoadGeoIPDB(path string) error {
+	db, err := geoip2.Open(path)
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	geoIPPath = path
+	geoIPErr = err
+	if err != nil {
+		return err
+	}
+
+	GeoIPDB = db
+	return nil
+}
+
+// Validate checks the current configuration and returns an error describing every problem found.
+// If strict startup is enabled, settings that are required but not configured are reported as well.
+func Validate() error {
+	var problems []string
+
+	reconfigureMu.RLock()
+	logDir := LogDir
+	reconfigureMu.RUnlock()
+
+	if strings.TrimSpace(logDir) == "" {
+		problems = append(problems, "log directory is empty")
+	}
+
+	minimumLevel := GetMinimumLogLevel()
+	if _, ok := LevelWeights[minimumLevel]; !ok {
+		problems = append(problems, "unknown minimum log level "+minimumLevel)
+	}
+
+	outputFormat := currentSettings().outputFormat
+	if !isValidFormat(outputFormat) {
+		problems = append(problems, "unknown output format "+outputFormat)
+	}
+
+	configMu.RLock()
+	if geoIPErr != nil {
+		problems = append(problems, "could not load GeoIP database "+geoIPPath+": "+geoIPErr.Error())
+	}
+
+	if strictStartup {
+		if requiredConfig.MinimumLogLevel && !minimumLogLevelConfigured {
+			problems = append(problems, "minimum log level is not configured")
+		}
+
+		if requiredConfig.GeoIPDB && GeoIPDB == nil && geoIPErr == nil {
+			problems = append(problems, "GeoIP database is not configured")
+		}
+	}
+	configMu.RUnlock()
+
+	if len(problems) > 0 {
+		return errors.New("invalid logger configuration: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+// useStrictStartup enables strict startup with the given required settings for the duration of the test.
+func useStrictStartup(t *testing.T, required RequireConfig) {
+	t.Helper()
+
+	SetStrictStartup(true)
+	SetRequiredConfig(required)
+	t.Cleanup(func() {
+		SetStrictStartup(false)
+		SetRequiredConfig(RequireConfig{MinimumLogLevel: true})
+		GeoIPDB = nil
+		geoIPPath = ""
+		geoIPErr = nil
+	})
+}
+
+func TestStrictStartupRequiresGeoIP(t *testing.T) {
+	useStrictStartup(t, RequireConfig{GeoIPDB: true})
+
+	err := Configure()
+	if err == nil || !strings.Contains(err.Error(), "GeoIP database is not configured") {
+		t.Errorf("expected the missing GeoIP database to be reported, got %v", err)
+	}
+}
+
+func TestStrictStartupReportsUnreadableGeoIP(t *testing.T) {
+	useStrictStartup(t, RequireConfig{GeoIPDB: true})
+
+	if LoadGeoIPDB("/nonexistent/GeoLite2-City.mmdb") == nil {
+		t.Fatal("expected loading a missing GeoIP database to fail")
+	}
+
+	err := Validate()
+	if err == nil || !strings.Contains(err.Error(), "/nonexistent/GeoLite2-City.mmdb") {
+		t.Errorf("expected the missing GeoIP path to be reported, got %v", err)
+	}
+}
+
+func TestNonStrictStartupIgnoresMissingGeoIP(t *testing.T) {
+	SetRequiredConfig(RequireConfig{GeoIPDB: true})
+	t.Cleanup(func() {
+		SetRequiredConfig(RequireConfig{MinimumLogLevel: true})
+	})
+
+	err := Configure()
+	if err != nil {
+		t.Errorf("expected no error without strict startup, got %v", err)
+	}
+}